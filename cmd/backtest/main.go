@@ -0,0 +1,114 @@
+// backtest reproduz klines históricos de um CSV pela estratégia e pelo trailing usados ao vivo
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+
+	"binance-bot/config"
+	"binance-bot/internal/backtest"
+	"binance-bot/internal/risk"
+	"binance-bot/internal/strategy"
+)
+
+func main() {
+	file := flag.String("file", "", "CSV de klines (open_time,open,high,low,close,volume,close_time), obrigatório")
+	symbol := flag.String("symbol", "BTCUSDT", "símbolo simulado")
+	strategyName := flag.String("strategy", "", "estratégia (padrão: a configurada para o símbolo)")
+	balance := flag.Float64("balance", 1000, "saldo inicial em USDT")
+	leverage := flag.Float64("leverage", 20, "alavancagem")
+	fee := flag.Float64("fee", 0.04, "taxa por execução em %")
+	slippage := flag.Float64("slippage", 0.01, "slippage por execução em %")
+	interval := flag.String("interval", "", "intervalo dos klines do CSV (padrão: o configurado para o símbolo)")
+	window := flag.Int("window", 100, "klines passados à estratégia a cada candle")
+	tradesOut := flag.String("trades", "backtest_trades.csv", "arquivo de saída com os trades")
+	equityOut := flag.String("equity", "backtest_equity.csv", "arquivo de saída com a curva de equity")
+	flag.Parse()
+	if *file == "" {
+		fmt.Fprintln(os.Stderr, "Informe o CSV de klines com -file")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Trailing e dimensionamento vêm das mesmas variáveis do bot
+	if err := godotenv.Load(); err != nil {
+		log.Println(".env não encontrado, usando variáveis de ambiente")
+	}
+	sizingCfg := config.LoadSizingConfig()
+	sizers, err := risk.NewSizers(sizingCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Estratégia e parâmetros também seguem a configuração do bot
+	strategyCfg := config.LoadStrategyConfig()
+	var strat strategy.Strategy
+	if *strategyName != "" {
+		strat, err = strategy.New(*strategyName, strategyCfg.Params[*strategyName])
+	} else {
+		var set *strategy.Set
+		if set, err = strategy.NewSet(strategyCfg); err == nil {
+			strat = set.For(*symbol)
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *interval == "" {
+		*interval = config.LoadEngineConfig().IntervalFor(*symbol)
+	}
+
+	klines, err := backtest.LoadCSV(*file)
+	if err != nil {
+		log.Fatalf("Erro ao carregar %s: %v", *file, err)
+	}
+
+	res, err := backtest.Run(klines, backtest.Config{
+		Symbol:         *symbol,
+		InitialBalance: *balance,
+		Leverage:       *leverage,
+		FeePct:         *fee,
+		SlippagePct:    *slippage,
+		Interval:       *interval,
+		Window:         *window,
+		Trailing:       config.LoadTrailingConfig(),
+		Sizer:          sizers.For(*symbol),
+		Strategy:       strat,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := writeFile(*tradesOut, func(f *os.File) error { return backtest.WriteTrades(f, res.Trades) }); err != nil {
+		log.Fatal(err)
+	}
+	if err := writeFile(*equityOut, func(f *os.File) error { return backtest.WriteEquity(f, res.Equity) }); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, t := range res.Trades {
+		fmt.Printf("%s %s %s → %s | %.4f → %.4f | qty %.4f | PnL %.4f (%s)\n",
+			t.Symbol, t.Side, t.EntryTime.Format("2006-01-02 15:04"), t.ExitTime.Format("2006-01-02 15:04"),
+			t.EntryPrice, t.ExitPrice, t.Quantity, t.PnL, t.Reason)
+	}
+	fmt.Printf("\n📈 %s (%d candles de %s, %s)\n", res.Summary(*balance), len(klines), *interval, strat.Name())
+	fmt.Printf("\n%s", res.Metrics(*balance))
+	fmt.Printf("Trades em %s, equity em %s\n", *tradesOut, *equityOut)
+}
+
+func writeFile(path string, write func(*os.File) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("erro ao gravar %s: %w", path, err)
+	}
+	return f.Close()
+}
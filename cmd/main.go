@@ -2,250 +2,549 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"math"
-	"net/http"
 	"os"
-	"strconv"
+	"os/signal"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
-	"github.com/joho/godotenv"
-
 	"binance-bot/config"
 	"binance-bot/internal/binance"
-	"binance-bot/internal/indicators"
+	"binance-bot/internal/engine"
 	"binance-bot/internal/logger"
+	"binance-bot/internal/protection"
+	"binance-bot/internal/reconcile"
+	"binance-bot/internal/risk"
+	"binance-bot/internal/sim"
+	"binance-bot/internal/state"
 	"binance-bot/internal/strategy"
 	"binance-bot/internal/telegram"
+	"binance-bot/internal/trailing"
 )
 
-type TrailingStatus struct {
-	MaxPnL float64
-	Side   string
-}
+// Intervalo de acompanhamento das posições abertas (trailing, stops e saídas)
+// e de atualização do saldo
+const positionCheckInterval = 2 * time.Second
 
-func countDecimals(step float64) int {
-	str := fmt.Sprintf("%f", step)
-	parts := strings.Split(str, ".")
-	decimals := strings.TrimRight(parts[1], "0")
-	return len(decimals)
-}
+// Máximo de workers fazendo chamadas REST ao mesmo tempo
+const maxConcurrentCalls = 4
 
-func getPositionInfo(client *binance.BinanceRestClient, symbol string, leverage float64) (bool, float64, string, float64, float64, error) {
-	markPriceURL := fmt.Sprintf("%s/fapi/v1/premiumIndex?symbol=%s", client.BaseURL, symbol)
-	resp, err := http.Get(markPriceURL)
+func getPositionInfo(client binance.Exchange, symbol string, leverage float64) (bool, float64, string, float64, float64, error) {
+	positions, err := client.GetPositions(symbol)
 	if err != nil {
-		return false, 0, "", 0, 0, fmt.Errorf("erro ao obter mark price: %v", err)
-	}
-	defer resp.Body.Close()
-	var markData struct {
-		MarkPrice string `json:"markPrice"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&markData); err != nil {
-		return false, 0, "", 0, 0, fmt.Errorf("erro ao decodificar mark price: %v", err)
+		return false, 0, "", 0, 0, err
 	}
-	markPrice, _ := strconv.ParseFloat(markData.MarkPrice, 64)
 
-	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
-	params := "timestamp=" + timestamp
-	signature := binance.Sign(params, client.APISecret)
-	url := fmt.Sprintf("%s/fapi/v2/positionRisk?%s&signature=%s", client.BaseURL, params, signature)
+	for _, p := range positions {
+		if p.Symbol != symbol {
+			continue
+		}
+		if p.PositionAmt == 0 {
+			return false, 0, "", 0, 0, nil
+		}
+		entryPrice, markPrice := p.EntryPrice, p.MarkPrice
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return false, 0, "", 0, 0, err
-	}
-	req.Header.Set("X-MBX-APIKEY", client.APIKey)
-	resp, err = http.DefaultClient.Do(req)
-	if err != nil {
-		return false, 0, "", 0, 0, err
-	}
-	defer resp.Body.Close()
+		var pnl float64
+		if p.PositionAmt > 0 {
+			pnl = (markPrice - entryPrice) / entryPrice * leverage * 100
+		} else {
+			pnl = (entryPrice - markPrice) / entryPrice * leverage * 100
+		}
 
-	var raw interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
-		return false, 0, "", 0, 0, err
+		side := "BUY"
+		if p.PositionAmt < 0 {
+			side = "SELL"
+		}
+
+		log.Printf("🔍 Position Debug | %s | Qty: %.2f | Entry: %.4f | Mark: %.4f | PnL: %.2f%%",
+			side, math.Abs(p.PositionAmt), entryPrice, markPrice, pnl)
+
+		return true, math.Abs(p.PositionAmt), side, entryPrice, pnl, nil
 	}
-	slice, ok := raw.([]interface{})
-	if !ok {
-		log.Println("⚠️ Resposta inesperada da Binance:", raw)
-		return false, 0, "", 0, 0, fmt.Errorf("formato inválido da resposta da Binance")
+	return false, 0, "", 0, 0, nil
+}
+
+// apiBackoff registra um erro da Binance e retorna quanto esperar antes de
+// voltar a chamar a API
+func apiBackoff(context string, err error) time.Duration {
+	switch {
+	case binance.IsIPBanned(err):
+		log.Printf("🚫 %s: IP banido temporariamente pela Binance: %v", context, err)
+		return 2 * time.Minute
+	case binance.IsRateLimited(err):
+		log.Printf("🐢 %s: limite de requisições atingido: %v", context, err)
+		return 30 * time.Second
+	case binance.IsTimestampOutsideRecvWindow(err):
+		log.Printf("🕒 %s: relógio local fora da recvWindow, verifique a sincronização: %v", context, err)
+	default:
+		log.Printf("⚠️ %s: %v", context, err)
 	}
+	return 0
+}
 
-	for _, item := range slice {
-		entry := item.(map[string]interface{})
-		if entry["symbol"] == symbol {
-			posAmt, _ := strconv.ParseFloat(entry["positionAmt"].(string), 64)
-			if posAmt == 0 {
-				return false, 0, "", 0, 0, nil
-			}
-			entryPrice, _ := strconv.ParseFloat(entry["entryPrice"].(string), 64)
+// openEntry é a execução de entrada de uma posição, usada no diário de
+// trades fechados
+type openEntry struct {
+	price float64
+	at    time.Time
+}
 
-			var pnl float64
-			if posAmt > 0 {
-				pnl = (markPrice - entryPrice) / entryPrice * leverage * 100
-			} else {
-				pnl = (entryPrice - markPrice) / entryPrice * leverage * 100
+// drainUserData processa os eventos pendentes do user data stream sem bloquear
+func drainUserData(user *binance.UserStream, fills *binance.FillAggregator, onFill func(binance.Fill)) {
+	for {
+		select {
+		case u := <-user.Orders():
+			if fill, done := fills.Add(u); done {
+				onFill(fill)
 			}
-
-			side := "BUY"
-			if posAmt < 0 {
-				side = "SELL"
+		case call := <-user.MarginCalls():
+			msg := fmt.Sprintf("🚨 MARGIN CALL | saldo cross %.2f USDT", call.CrossWalletBalance)
+			for _, p := range call.Positions {
+				msg += fmt.Sprintf("\n- %s qty %v | mark %.4f | PnL %.2f | manutenção %.2f",
+					p.Symbol, p.PositionAmt, p.MarkPrice, p.UnrealizedPnL, p.MaintenanceMargin)
 			}
+			log.Println(msg)
+			telegram.SendMessage(msg)
+		case acc := <-user.Accounts():
+			// Saldo e posições ainda são lidos pelo REST a cada ciclo
+			log.Printf("📒 ACCOUNT_UPDATE (%s): %d saldos, %d posições", acc.Reason, len(acc.Balances), len(acc.Positions))
+		default:
+			return
+		}
+	}
+}
 
-			log.Printf("🔍 Position Debug | %s | Qty: %.2f | Entry: %.4f | Mark: %.4f | PnL: %.2f%%",
-				side, math.Abs(posAmt), entryPrice, markPrice, pnl)
+// feedPaper repassa o mark price e o funding do stream à conta simulada, que
+// dispara stops, take profits e liquidações como a corretora faria
+func feedPaper(ctx context.Context, market *binance.MarketStream, paper *sim.Exchange) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-market.MarkPrices():
+			paper.SetFundingRate(ev.Symbol, ev.FundingRate*100)
+			paper.Tick(ev.Symbol, ev.MarkPrice, time.UnixMilli(ev.EventTime))
+		}
+	}
+}
 
-			return true, math.Abs(posAmt), side, entryPrice, pnl, nil
+// drainPaperFills processa as execuções pendentes da conta simulada sem bloquear
+func drainPaperFills(paper *sim.Exchange, onFill func(binance.Fill)) {
+	for {
+		select {
+		case fill := <-paper.Fills():
+			onFill(fill)
+		default:
+			return
 		}
 	}
-	return false, 0, "", 0, 0, nil
+}
+
+// notifyRejection registra e envia ao Telegram uma ordem barrada pelo risco
+func notifyRejection(err error) {
+	log.Printf("⛔ %v", err)
+	var rej *risk.Rejection
+	if errors.As(err, &rej) {
+		telegram.SendMessage(fmt.Sprintf("⛔ Ordem bloqueada pelo risco\n%s | %s\n%s", rej.Symbol, rej.Reason, rej.Detail))
+		return
+	}
+	telegram.SendMessage("⛔ " + err.Error())
+}
+
+// notifyBreaker avisa que o circuit breaker disparou e novas entradas estão suspensas
+func notifyBreaker(breaker *risk.CircuitBreaker) {
+	_, reason := breaker.Tripped()
+	msg := fmt.Sprintf("🛑 Circuit breaker disparado: %s\nNovas entradas suspensas até reset manual (-reset-breaker)", reason)
+	log.Println(msg)
+	telegram.SendMessage(msg)
 }
 
 func main() {
-	if err := godotenv.Load(); err != nil {
-		log.Println(".env não encontrado, usando variáveis de ambiente")
+	resetBreaker := flag.Bool("reset-breaker", false, "rearma o circuit breaker de perda diária/drawdown")
+	flag.Parse()
+
+	cfg := config.LoadConfig()
+	logger.SetPrefix(cfg.JournalPrefix)
+	if cfg.Paper.Enabled {
+		// O paper trading lê preços da mainnet; ordens e posições ficam na conta simulada
+		cfg.Testnet = false
+		log.Printf("📝 Paper trading: dados de mercado reais, execuções simuladas em %s", cfg.Paper.StateFile)
+		log.Printf("📝 Estado em %s, circuit breaker em %s e diários com prefixo %s", cfg.StateFile, cfg.Risk.BreakerStateFile, cfg.JournalPrefix)
+	} else {
+		if cfg.APIKey == "" || cfg.APISecret == "" {
+			log.Fatal("Faltando BINANCE_API_KEY ou BINANCE_API_SECRET")
+		}
+		if cfg.Testnet {
+			log.Println("🧪 Operando na testnet da Binance (BINANCE_TESTNET=false para a mainnet)")
+		} else {
+			msg := "🚨 ATENÇÃO: operando na MAINNET da Binance com dinheiro real (BINANCE_TESTNET=false)"
+			log.Println(msg)
+			telegram.SendMessage(msg)
+		}
+	}
+	client := binance.NewBinanceRestClient(cfg)
+
+	// SIGINT/SIGTERM cancelam ctx: streams e workers param depois de concluir
+	// o que estão fazendo e o desligamento segue SHUTDOWN_MODE
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	client.SetContext(ctx)
+	riskEngine := risk.NewEngine(cfg.Risk)
+	sizers, err := risk.NewSizers(cfg.Sizing)
+	if err != nil {
+		log.Fatal(err)
+	}
+	strategies, err := strategy.NewSet(cfg.Strategy)
+	if err != nil {
+		log.Fatal(err)
+	}
+	breaker, err := risk.NewCircuitBreaker(cfg.Risk)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *resetBreaker {
+		if err := breaker.Reset(); err != nil {
+			log.Fatal(err)
+		}
+		log.Println("🔓 Circuit breaker rearmado manualmente")
+	}
+	if tripped, reason := breaker.Tripped(); tripped {
+		log.Printf("🛑 Circuit breaker disparado: %s (use -reset-breaker para rearmar)", reason)
 	}
 
-	apiKey := os.Getenv("BINANCE_API_KEY")
-	apiSecret := os.Getenv("BINANCE_API_SECRET")
-	if apiKey == "" || apiSecret == "" {
-		log.Fatal("Faltando BINANCE_API_KEY ou BINANCE_API_SECRET")
+	store, err := state.Open(cfg.StateFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// O histórico de trades salvo alimenta os sizers que dependem dele (kelly)
+	for _, pnl := range store.Trades() {
+		sizers.RecordTrade(pnl)
 	}
 
-	cfg := config.Config{
-		APIKey:    apiKey,
-		APISecret: apiSecret,
-		Testnet:   true,
+	if err := client.SyncTime(); err != nil {
+		log.Printf("⚠️ %v", err)
 	}
-	client := binance.NewBinanceRestClient(cfg)
+	client.StartTimeSync(ctx, 10*time.Minute)
 
-	symbols := []string{"ETHUSDT", "BTCUSDT", "XRPUSDT", "BNBUSDT", "ADAUSDT", "SOLUSDT", "MATICUSDT", "DOTUSDT", "AVAXUSDT", "LINKUSDT"}
 	leverage := 20.0
-	stepSizes := map[string]float64{
-		"ETHUSDT": 0.01, "BTCUSDT": 0.001, "XRPUSDT": 0.1, "BNBUSDT": 0.01,
-		"ADAUSDT": 1.0, "SOLUSDT": 0.01, "MATICUSDT": 1.0, "DOTUSDT": 0.1,
-		"AVAXUSDT": 0.01, "LINKUSDT": 0.1,
+	if err := client.LoadExchangeInfo(); err != nil {
+		log.Fatalf("Erro ao carregar exchangeInfo: %v", err)
+	}
+	var symbols []string
+	for _, symbol := range cfg.Symbols {
+		if _, err := client.SymbolRules(symbol); err != nil {
+			log.Printf("⚠️ %v, ignorando", err)
+			continue
+		}
+		symbols = append(symbols, symbol)
+	}
+	// A janela de klines precisa cobrir a estratégia mais exigente, inclusive
+	// os candles agregados a partir dela nos intervalos de confirmação
+	window := strategies.MaxLookback()
+	for _, symbol := range symbols {
+		need, err := engine.Window(cfg.Engine.IntervalFor(symbol), strategies.Timeframes(symbol), strategies.TimeframeLookback(symbol))
+		if err != nil {
+			log.Fatalf("%s: %v", symbol, err)
+		}
+		window = max(window, need)
+	}
+	if cfg.Engine.WindowSize < window {
+		log.Printf("⚠️ KLINE_WINDOW=%d menor que o lookback das estratégias, usando %d", cfg.Engine.WindowSize, window)
+		cfg.Engine.WindowSize = window
 	}
+	log.Printf("📐 Janela de %d klines por símbolo/intervalo", cfg.Engine.WindowSize)
 
-	trailings := make(map[string]*TrailingStatus)
+	// Corretora usada pelo bot: a Binance ou, no paper trading, a conta simulada
+	var exchange binance.Exchange = client
+	var paper *sim.Exchange
+	if cfg.Paper.Enabled {
+		simCfg := sim.DefaultConfig(cfg.Paper.InitialBalance, leverage)
+		simCfg.TakerFeePct = cfg.Paper.TakerFeePct
+		simCfg.MakerFeePct = cfg.Paper.MakerFeePct
+		simCfg.SlippagePct = cfg.Paper.SlippagePct
+		paper, err = sim.Open(cfg.Paper.StateFile, simCfg, client)
+		if err != nil {
+			log.Fatal(err)
+		}
+		exchange = paper
+		telegram.SendMessage("📝 Bot iniciado em paper trading: ordens simuladas, sem envio à Binance")
+	}
 
-	for {
-		saldo := client.GetUSDTBalance()
-		fmt.Printf("\n💰 Saldo USDT: %.2f\n", saldo)
+	market := binance.NewMarketStream(client, cfg.Engine.WindowSize)
+	eng := engine.New(market, cfg.Engine)
+	if err := eng.Subscribe(symbols, strategies.Timeframes); err != nil {
+		log.Fatal(err)
+	}
+	for _, symbol := range symbols {
+		if intervals := strategies.Timeframes(symbol); len(intervals) > 0 {
+			log.Printf("🔭 %s: sinais de %s confirmados pela tendência em %v", symbol, cfg.Engine.IntervalFor(symbol), intervals)
+		}
+	}
+	var streams sync.WaitGroup
+	streams.Add(3)
+	go func() { defer streams.Done(); market.Run(ctx) }()
+	go func() { defer streams.Done(); eng.Run(ctx) }()
+
+	// Execuções chegam pelo user data stream (ou da conta simulada); o PnL
+	// realizado de cada ordem encerrada (inclusive stops e TPs executados pela
+	// corretora) alimenta os sizers e o circuit breaker
+	// O user data stream fica aberto no desligamento para receber as execuções
+	// do flatten
+	userCtx, stopUser := context.WithCancel(context.Background())
+	defer stopUser()
+	var user *binance.UserStream
+	if paper != nil {
+		go func() { defer streams.Done(); feedPaper(ctx, market, paper) }()
+	} else {
+		user = binance.NewUserStream(client)
+		go func() { defer streams.Done(); user.Run(userCtx) }()
+	}
+	var fills binance.FillAggregator
+	entries := make(map[string]openEntry)
+	closed := make(map[string]bool) // símbolos com fechamento já registrado
+	onFill := func(fill binance.Fill) {
+		if !fill.Closing() {
+			log.Printf("💸 %s %s executada: qty %v @ %.4f | taxa %.4f %s",
+				fill.Side, fill.Symbol, fill.Quantity, fill.AvgPrice, fill.Commission, fill.CommissionAsset)
+			entries[fill.Symbol] = openEntry{price: fill.AvgPrice, at: time.Now()}
+			return
+		}
+		lucroReal := fill.NetPnL()
+		entry, ok := entries[fill.Symbol]
+		if !ok {
+			// Entrada anterior a um restart: usa o estado salvo
+			if p, found := store.Position(fill.Symbol); found {
+				entry = openEntry{price: p.EntryPrice, at: p.OpenedAt}
+			}
+		}
+		delete(entries, fill.Symbol)
+		closed[fill.Symbol] = true
+		logger.LogClosedTrade(fill.Symbol, trailing.CloseSide(fill.Side), fill.Quantity, entry.price, fill.AvgPrice, lucroReal, entry.at)
+		telegram.SendMessage(fmt.Sprintf("🔎 Lucro real %s: %.4f USDT (PnL %.4f, taxa %.4f %s)",
+			fill.Symbol, lucroReal, fill.RealizedPnL, fill.Commission, fill.CommissionAsset))
+		sizers.RecordTrade(lucroReal)
+		store.RecordTrade(lucroReal)
+		if breaker.RecordTrade(lucroReal) {
+			notifyBreaker(breaker)
+		}
+	}
 
+	drain := func() {
+		if paper != nil {
+			drainPaperFills(paper, onFill)
+		} else {
+			drainUserData(user, &fills, onFill)
+		}
+	}
+	// settle processa as execuções até registrar o fechamento de cada símbolo
+	// ou esgotar o prazo
+	settle := func(symbols []string, timeout time.Duration) {
 		for _, symbol := range symbols {
-			stepSize := stepSizes[symbol]
-			rawKlines := client.GetKlines(symbol, "1m", 100)
-			if rawKlines == nil || len(rawKlines) == 0 {
-				log.Printf("⚠️ Falha ao obter klines para %s, pulando...", symbol)
-				continue
+			delete(closed, symbol)
+		}
+		deadline := time.Now().Add(timeout)
+		for {
+			drain()
+			var pending []string
+			for _, symbol := range symbols {
+				if !closed[symbol] {
+					pending = append(pending, symbol)
+				}
 			}
-			klines := indicators.ConvertToKlines(rawKlines)
-			closes := indicators.ExtractClosePrices(klines)
-			volumes := indicators.ExtractVolumes(klines)
-			macdLine, signalLine, _ := indicators.ComputeMACD(closes, 12, 26, 9)
-			rsi := indicators.ComputeRSI(closes, 14)
-			volMA := indicators.ComputeVolumeMA(volumes, 14)
-			currentPrice := client.GetMarkPrice(symbol)
+			if len(pending) == 0 {
+				return
+			}
+			if time.Now().After(deadline) {
+				log.Printf("⚠️ Fechamento sem execução registrada no diário: %s", strings.Join(pending, ", "))
+				return
+			}
+			time.Sleep(200 * time.Millisecond)
+		}
+	}
 
-			sig := strategy.EvaluateSignal(klines, symbol)
-			inPosition, qty, side, _, pnl, err := getPositionInfo(client, symbol, leverage)
+	b := &bot{
+		cfg:        cfg,
+		leverage:   leverage,
+		client:     exchange,
+		market:     market,
+		coord:      engine.NewCoordinator(maxConcurrentCalls),
+		riskEngine: riskEngine,
+		sizers:     sizers,
+		strategies: strategies,
+		breaker:    breaker,
+		protector:  protection.NewManager(exchange),
+		store:      store,
+	}
 
-			if err != nil {
-				log.Printf("Erro ao buscar posição para %s: %v\n", symbol, err)
-				continue
+	// refresh atualiza saldo, equity e eventos do user data stream
+	refresh := func() time.Duration {
+		var balance, equity float64
+		var balanceErr, equityErr error
+		fetchedAt := time.Now()
+		b.coord.Call(func() {
+			balance, balanceErr = exchange.GetUSDTBalance()
+			if balanceErr == nil {
+				equity, equityErr = exchange.GetAccountEquity()
 			}
+		})
+		if balanceErr != nil {
+			return apiBackoff("saldo", balanceErr)
+		}
+		b.coord.SetBalance(balance, fetchedAt)
+		fmt.Printf("\n💰 Saldo USDT: %.2f\n", balance)
+		drain()
+		if equityErr != nil {
+			return apiBackoff("equity", equityErr)
+		}
+		if breaker.UpdateEquity(equity) {
+			notifyBreaker(breaker)
+		}
+		return 0
+	}
+	b.coord.Pause(refresh())
 
-			if inPosition {
-				trailing, exists := trailings[symbol]
-				if !exists {
-					trailings[symbol] = &TrailingStatus{MaxPnL: pnl, Side: side}
-					continue
-				}
-				if pnl > trailing.MaxPnL {
-					trailing.MaxPnL = pnl
-				}
+	// Confere posições e ordens abertas com o estado salvo antes de operar
+	report, err := reconcile.Run(exchange, store, symbols)
+	if err != nil {
+		log.Fatalf("Erro na reconciliação com a corretora: %v", err)
+	}
+	log.Println(report.Summary())
+	if !report.Clean() {
+		telegram.SendMessage(report.Summary())
+	}
 
-				shouldExit := false
-				if trailing.MaxPnL >= 3.0 && pnl <= trailing.MaxPnL-1.0 {
-					shouldExit = true
-				}
-				if pnl <= -5.0 {
-					shouldExit = true
-				}
+	// Um worker por símbolo; o main só distribui candles fechados e atualiza a conta
+	workers := make(map[string]*worker, len(symbols))
+	var running sync.WaitGroup
+	for _, symbol := range symbols {
+		w := newWorker(b, symbol)
+		workers[symbol] = w
+		running.Add(1)
+		go func() { defer running.Done(); w.run(ctx) }()
+	}
 
-				if shouldExit {
-					closeSide := "SELL"
-					if trailing.Side == "SELL" {
-						closeSide = "BUY"
-					}
-					if qty < stepSize {
-						log.Printf("❌ Quantidade abaixo do mínimo (%s): %.4f < %.4f", symbol, qty, stepSize)
-						continue
-					}
-					saldoAntes := client.GetUSDTBalance()
-					ok := client.PlaceMarketOrder(symbol, closeSide, qty, true)
-					if ok {
-						time.Sleep(1 * time.Second)
-						saldoDepois := client.GetUSDTBalance()
-						lucroReal := saldoDepois - saldoAntes
-						msg := fmt.Sprintf("🔴 %s (MaxPnL %.2f%% → %.2f%%) Fechando %s Qty: %.3f", symbol, trailing.MaxPnL, pnl, trailing.Side, qty)
-						msgLucro := fmt.Sprintf("🔎 Lucro real: %.4f USDT", lucroReal)
-						telegram.SendMessage(msg + "\n" + msgLucro)
-						logger.LogTrade(symbol, "TRAILING-CLOSE", qty, currentPrice, saldoDepois)
-						delete(trailings, symbol)
-					}
-				}
+	ticker := time.NewTicker(positionCheckInterval)
+	defer ticker.Stop()
+	for ctx.Err() == nil {
+		select {
+		case <-ctx.Done():
+
+		case bar := <-eng.Bars():
+			w, ok := workers[bar.Symbol]
+			if !ok {
 				continue
 			}
+			select {
+			case w.bars <- bar:
+			default:
+				log.Printf("⚠️ %s: worker ocupado, candle %s descartado", bar.Symbol, bar.Interval)
+			}
+
+		case <-ticker.C:
+			if !b.coord.Paused() {
+				b.coord.Pause(refresh())
+			}
+		}
+	}
+
+	stop() // um segundo sinal encerra o processo na hora
+	shutdown(b, client, workers, &running, settle)
+	stopUser()
+	if !waitTimeout(&streams, time.Duration(cfg.Shutdown.Timeout)*time.Second) {
+		log.Println("⚠️ Streams não encerraram a tempo")
+	}
+}
+
+// shutdown espera os workers terminarem o que estavam fazendo e fecha ou
+// mantém as posições conforme SHUTDOWN_MODE. O estado já é gravado a cada
+// alteração, então o que estiver aberto é retomado no próximo boot. Workers
+// que não pararam no prazo não são tocados, para não disputar a posição com
+// eles. settle registra no diário as execuções do flatten.
+func shutdown(b *bot, client *binance.BinanceRestClient, workers map[string]*worker, running *sync.WaitGroup, settle func([]string, time.Duration)) {
+	cfg := b.cfg.Shutdown
+	log.Printf("🛑 Encerrando (modo %s)...", cfg.Mode)
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if !waitTimeout(running, timeout) {
+		log.Printf("⚠️ Workers não terminaram em %v, seguindo com o desligamento", timeout)
+	}
 
-			rawQty := saldo * 0.90 * leverage / currentPrice
-			if rawQty < stepSize {
-				log.Printf("❌ Quantidade insuficiente para %s (min: %.4f)", symbol, stepSize)
+	// As consultas do desligamento usam um contexto próprio, já que o
+	// principal foi cancelado
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	client.SetContext(ctx)
+
+	var msg string
+	switch cfg.Mode {
+	case "flatten":
+		var failed, flattened, busy []string
+		for symbol, w := range workers {
+			if !w.stopped() {
+				busy = append(busy, symbol)
 				continue
 			}
-			decimals := countDecimals(stepSize)
-			factor := math.Pow(10, float64(decimals))
-			orderQty := math.Floor(rawQty*factor) / factor
-
-			var orderSide string
-			switch sig {
-			case strategy.BuySignal:
-				orderSide = "BUY"
-			case strategy.SellSignal:
-				orderSide = "SELL"
-			default:
-				fmt.Printf("⚪ %s: Nenhum sinal válido\n", symbol)
+			closed, err := w.flatten()
+			if err != nil {
+				log.Printf("❌ %v", err)
+				failed = append(failed, symbol)
+			}
+			if closed {
+				flattened = append(flattened, symbol)
+			}
+		}
+		if len(flattened) > 0 {
+			settle(flattened, timeout)
+		}
+		for _, symbol := range flattened {
+			b.store.RemovePosition(symbol)
+		}
+		msg = "🛑 Bot encerrado, posições fechadas"
+		if len(failed) > 0 {
+			msg = fmt.Sprintf("🛑 Bot encerrado, falha ao fechar: %s", strings.Join(failed, ", "))
+		}
+		if len(busy) > 0 {
+			msg += fmt.Sprintf("\n⚠️ Workers ainda ocupados, posições não fechadas: %s", strings.Join(busy, ", "))
+		}
+	default:
+		var open, unprotected []string
+		for symbol := range workers {
+			if _, ok := b.store.Position(symbol); !ok {
 				continue
 			}
-
-			saldoAntes := client.GetUSDTBalance()
-			msg := fmt.Sprintf("🟢 %s %s | qty %.3f | alav %.0fx", orderSide, symbol, orderQty, leverage)
-			fmt.Println(msg)
-			ok := client.PlaceMarketOrder(symbol, orderSide, orderQty, false)
-			if ok {
-				time.Sleep(1 * time.Second)
-				saldoDepois := client.GetUSDTBalance()
-				custo := saldoAntes - saldoDepois
-				msgDet := fmt.Sprintf("%s\n\n📊 Indicadores:\n- MACD: %.4f / %.4f\n- RSI: %.2f\n- Volume: %.2f vs MA: %.2f\n💰 Preço: %.4f | Quantidade: %.1f | Custo: %.4f | Saldo: %.2f",
-					msg,
-					macdLine[len(macdLine)-1],
-					signalLine[len(signalLine)-1],
-					rsi[len(rsi)-1],
-					volumes[len(volumes)-1],
-					volMA,
-					currentPrice,
-					orderQty,
-					custo,
-					saldoDepois)
-				telegram.SendMessage(msgDet)
-				logger.LogTrade(symbol, orderSide, orderQty, currentPrice, saldoDepois)
+			open = append(open, symbol)
+			if o, ok := b.protector.Get(symbol); !ok || (o.StopOrderID == 0 && o.TrailingOrderID == 0) {
+				unprotected = append(unprotected, symbol)
 			}
 		}
-		time.Sleep(2 * time.Second)
+		msg = "🛑 Bot encerrado sem posições abertas"
+		if len(open) > 0 {
+			msg = fmt.Sprintf("🛑 Bot encerrado, posições mantidas com stops na corretora: %s", strings.Join(open, ", "))
+		}
+		if len(unprotected) > 0 {
+			msg += fmt.Sprintf("\n⚠️ Sem stop na corretora: %s", strings.Join(unprotected, ", "))
+		}
+	}
+	log.Println(msg)
+	telegram.SendMessage(msg)
+}
+
+func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
 	}
 }
@@ -0,0 +1,50 @@
+// cmd/main_test.go
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"binance-bot/config"
+	"binance-bot/internal/logger"
+	"binance-bot/internal/risk"
+	"binance-bot/internal/state"
+)
+
+func TestPaperTradingSeparatesFiles(t *testing.T) {
+	t.Chdir(t.TempDir())
+	for _, key := range []string{"STATE_FILE", "RISK_BREAKER_STATE_FILE"} {
+		t.Setenv(key, "")
+	}
+	t.Setenv("PAPER_TRADING", "true")
+	cfg := config.LoadConfig()
+	logger.SetPrefix(cfg.JournalPrefix)
+	defer logger.SetPrefix("")
+
+	// Tudo o que o bot grava: estado, histórico do sizer, breaker e diários
+	store, err := state.Open(cfg.StateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.SetPosition("BTCUSDT", state.Position{Side: "BUY", EntryPrice: 100, Quantity: 1, OpenedAt: time.Now()})
+	store.RecordTrade(-1)
+	breaker, err := risk.NewCircuitBreaker(cfg.Risk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	breaker.UpdateEquity(1000)
+	breaker.RecordTrade(-1)
+	logger.LogTrade("BTCUSDT", "BUY", 1, 100, 1000)
+	logger.LogClosedTrade("BTCUSDT", "BUY", 1, 100, 99, -1, time.Now())
+	logger.LogSignal("BTCUSDT", "rsi_macd", "BUY", 0.5, 100, 0, 0, "", "")
+
+	for _, live := range []string{"bot_state.json", "breaker_state.json", "trades.csv", "closed_trades.csv", "signals.csv"} {
+		if _, err := os.Stat(live); !os.IsNotExist(err) {
+			t.Errorf("paper trading abriu o arquivo da conta real %s", live)
+		}
+		if _, err := os.Stat("paper_" + live); err != nil {
+			t.Errorf("paper_%s não foi gravado: %v", live, err)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+// report calcula as métricas de desempenho do diário de trades fechados do bot
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"binance-bot/internal/metrics"
+)
+
+func main() {
+	file := flag.String("file", "closed_trades.csv", "diário de trades fechados gravado pelo bot (paper_closed_trades.csv no paper trading)")
+	balance := flag.Float64("balance", 0, "equity no início do período em USDT")
+	flag.Parse()
+
+	if *balance <= 0 {
+		log.Fatal("Informe o equity inicial com -balance")
+	}
+	trades, err := metrics.LoadJournal(*file)
+	if err != nil {
+		log.Fatalf("Erro ao carregar %s: %v", *file, err)
+	}
+	if len(trades) == 0 {
+		log.Fatalf("Nenhum trade fechado em %s", *file)
+	}
+	fmt.Print(metrics.Compute(trades, nil, *balance))
+}
@@ -0,0 +1,416 @@
+// worker.go com um worker por símbolo e as dependências compartilhadas entre eles
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"binance-bot/config"
+	"binance-bot/internal/binance"
+	"binance-bot/internal/engine"
+	"binance-bot/internal/logger"
+	"binance-bot/internal/protection"
+	"binance-bot/internal/risk"
+	"binance-bot/internal/state"
+	"binance-bot/internal/strategy"
+	"binance-bot/internal/telegram"
+	"binance-bot/internal/trailing"
+)
+
+// bot reúne o que os workers compartilham. Tudo aqui é seguro para uso
+// concorrente; o estado de conta passa pelo coordinator.
+type bot struct {
+	cfg        config.Config
+	leverage   float64
+	client     binance.Exchange
+	market     *binance.MarketStream
+	coord      *engine.Coordinator
+	riskEngine *risk.Engine
+	sizers     *risk.Sizers
+	strategies *strategy.Set
+	breaker    *risk.CircuitBreaker
+	protector  *protection.Manager
+	store      *state.Store
+}
+
+// markPrice usa o mark price do websocket e cai para o REST se ainda não chegou
+func (b *bot) markPrice(symbol string) (float64, error) {
+	if p, ok := b.market.MarkPrice(symbol); ok {
+		return p, nil
+	}
+	return b.client.GetMarkPrice(symbol)
+}
+
+// halted informa se o circuit breaker suspendeu novas entradas
+func (b *bot) halted() bool {
+	tripped, _ := b.breaker.Tripped()
+	return tripped
+}
+
+// protect cria stop loss e take profit na corretora para uma posição recém-aberta
+// e, no modo de trailing exchange, o TRAILING_STOP_MARKET nativo. cfg já traz
+// os níveis próprios da posição.
+func (b *bot) protect(rules binance.SymbolRules, cfg config.TrailingConfig, side string, entryPrice, qty float64) {
+	symbol := rules.Symbol
+	stopPrice := rules.RoundPrice(trailing.PriceForPnL(side, entryPrice, b.leverage, cfg.StopLossPnL))
+	var tpPrice float64
+	if cfg.TakeProfitPnL > 0 {
+		tpPrice = rules.RoundPrice(trailing.PriceForPnL(side, entryPrice, b.leverage, cfg.TakeProfitPnL))
+	}
+	err := b.protector.Place(symbol, side, stopPrice, tpPrice)
+	if err == nil && cfg.Mode == trailing.ModeExchange {
+		activation := rules.RoundPrice(trailing.PriceForPnL(side, entryPrice, b.leverage, cfg.ActivatePnL))
+		err = b.protector.PlaceTrailing(symbol, qty, activation, trailing.CallbackRate(cfg, b.leverage))
+	}
+	if err != nil {
+		msg := fmt.Sprintf("⚠️ %s: posição sem proteção completa na corretora: %v", symbol, err)
+		log.Println(msg)
+		telegram.SendMessage(msg)
+	}
+}
+
+// worker cuida de um símbolo: avalia entradas a cada candle fechado e
+// acompanha a posição aberta. O trailing é exclusivo do worker.
+type worker struct {
+	*bot
+	symbol    string
+	bars      chan engine.Bar
+	done      chan struct{} // fechado quando run retorna
+	status    *trailing.Status
+	stopRetry time.Time // próxima tentativa de recriar um stop ausente
+}
+
+// newWorker cria o worker retomando o trailing e as proteções salvos antes
+// de um restart
+func newWorker(b *bot, symbol string) *worker {
+	w := &worker{bot: b, symbol: symbol, bars: make(chan engine.Bar, 4), done: make(chan struct{})}
+	if p, ok := b.store.Position(symbol); ok {
+		w.status = &trailing.Status{MaxPnL: p.MaxPnL, Side: p.Side}
+		if p.Protection.StopOrderID != 0 || p.Protection.TakeProfitOrderID != 0 || p.Protection.TrailingOrderID != 0 {
+			b.protector.Restore(symbol, p.Protection)
+		}
+		b.riskEngine.UpdatePosition(symbol, p.Notional)
+		log.Printf("♻️ %s: retomando %s de %.4f (MaxPnL %.2f%%)", symbol, p.Side, p.EntryPrice, p.MaxPnL)
+	}
+	return w
+}
+
+// exits retorna os níveis de saída da posição do símbolo: os da configuração
+// com o stop e o take profit sugeridos pelo sinal de entrada, se houver
+func (w *worker) exits() config.TrailingConfig {
+	p, _ := w.store.Position(w.symbol)
+	return trailing.WithExits(w.cfg.Trailing, p.StopLossPnL, p.TakeProfitPnL)
+}
+
+// saveProtection grava no estado as ordens de proteção atuais do símbolo
+func (w *worker) saveProtection() {
+	if o, ok := w.protector.Get(w.symbol); ok {
+		w.store.SetProtection(w.symbol, o)
+	}
+}
+
+// run processa candles e acompanha a posição até ctx ser cancelado
+func (w *worker) run(ctx context.Context) {
+	defer close(w.done)
+	ticker := time.NewTicker(positionCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case bar := <-w.bars:
+			if w.coord.Paused() {
+				log.Printf("⏸️ %s: candle %s ignorado, API em pausa", w.symbol, bar.Interval)
+				continue
+			}
+			w.coord.Call(func() { w.coord.Pause(w.evaluate(bar)) })
+		case <-ticker.C:
+			if w.coord.Paused() {
+				continue
+			}
+			w.coord.Call(func() { w.coord.Pause(w.manage()) })
+		}
+	}
+}
+
+// stopped informa se run já retornou; só então o estado do worker pode ser
+// lido por outra goroutine
+func (w *worker) stopped() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// flatten fecha a posição do símbolo a mercado e cancela as proteções. Usado
+// no desligamento com SHUTDOWN_MODE=flatten, com o worker já parado. A
+// posição salva fica no estado até a execução ser registrada no diário;
+// closed informa se uma ordem de fechamento foi enviada.
+func (w *worker) flatten() (closed bool, err error) {
+	symbol := w.symbol
+	inPosition, qty, side, _, _, err := getPositionInfo(w.client, symbol, w.leverage)
+	if err != nil {
+		return false, fmt.Errorf("erro ao consultar posição de %s: %w", symbol, err)
+	}
+	if !inPosition {
+		return false, nil
+	}
+	if _, err := w.client.PlaceMarketOrder(symbol, trailing.CloseSide(side), qty, true); err != nil {
+		return false, fmt.Errorf("erro ao fechar %s: %w", symbol, err)
+	}
+	if err := w.protector.Cancel(symbol); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+	w.status = nil
+	w.riskEngine.UpdatePosition(symbol, 0)
+	log.Printf("🔴 %s: posição %s de %v fechada no desligamento", symbol, side, qty)
+	return true, nil
+}
+
+// manage acompanha a posição aberta: trailing, proteção e saída
+func (w *worker) manage() time.Duration {
+	symbol, cfg := w.symbol, w.cfg
+	rules, err := w.client.SymbolRules(symbol)
+	if err != nil {
+		return apiBackoff("regras "+symbol, err)
+	}
+	currentPrice, err := w.markPrice(symbol)
+	if err != nil {
+		return apiBackoff("mark price "+symbol, err)
+	}
+	inPosition, qty, side, entryPrice, pnl, err := getPositionInfo(w.client, symbol, w.leverage)
+	if err != nil {
+		return apiBackoff("posição "+symbol, err)
+	}
+
+	if !inPosition {
+		w.riskEngine.UpdatePosition(symbol, 0)
+		// Posição fechada pela corretora (stop/TP): limpa o que sobrou
+		if _, ok := w.protector.Get(symbol); ok {
+			if err := w.protector.Cancel(symbol); err != nil {
+				log.Printf("⚠️ %v", err)
+			}
+		}
+		w.status = nil
+		w.store.RemovePosition(symbol)
+		return 0
+	}
+
+	w.riskEngine.UpdatePosition(symbol, qty*currentPrice)
+	if w.status != nil && w.status.Side != side {
+		log.Printf("⚠️ %s: posição na corretora (%s) difere do estado salvo (%s), recomeçando o trailing", symbol, side, w.status.Side)
+		w.status = nil
+	}
+	if w.status == nil {
+		// Posição desconhecida (aberta fora do bot ou estado perdido)
+		w.status = &trailing.Status{MaxPnL: pnl, Side: side}
+		w.store.SetPosition(symbol, state.Position{
+			Side:       side,
+			EntryPrice: entryPrice,
+			Quantity:   qty,
+			Notional:   qty * currentPrice,
+			OpenedAt:   time.Now(),
+			MaxPnL:     pnl,
+		})
+		if _, ok := w.protector.Get(symbol); !ok {
+			w.protect(rules, w.cfg.Trailing, side, entryPrice, qty)
+		}
+		w.saveProtection()
+		return 0
+	}
+	status, tc := w.status, w.exits()
+	w.ensureStop(rules, tc, side, entryPrice, qty)
+
+	shouldExit := status.Update(pnl, tc)
+	w.store.UpdateMaxPnL(symbol, status.MaxPnL)
+	if !shouldExit && tc.Mode == trailing.ModeLocal && status.Active(tc) {
+		stopPrice := rules.RoundPrice(trailing.PriceForPnL(side, entryPrice, w.leverage, status.StopPnL(tc)))
+		if err := w.protector.UpdateStop(symbol, stopPrice); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+		w.saveProtection()
+	}
+	if w.halted() && cfg.Risk.FlattenOnTrip {
+		shouldExit = true
+	}
+	if !shouldExit {
+		return 0
+	}
+
+	closeSide := trailing.CloseSide(status.Side)
+	if qty < rules.MarketMinQty {
+		log.Printf("❌ Quantidade abaixo do mínimo (%s): %v < %v", symbol, qty, rules.MarketMinQty)
+		return 0
+	}
+	closeOrder := risk.Order{Symbol: symbol, Side: closeSide, Quantity: qty, Price: currentPrice, Leverage: w.leverage, ReduceOnly: true}
+	if err := w.riskEngine.Check(closeOrder); err != nil {
+		notifyRejection(err)
+		return 0
+	}
+	if _, err := w.client.PlaceMarketOrder(symbol, closeSide, qty, true); err != nil {
+		telegram.SendMessage(fmt.Sprintf("❌ Falha ao fechar %s: %v", symbol, err))
+		return apiBackoff("fechamento "+symbol, err)
+	}
+	msg := fmt.Sprintf("🔴 %s (MaxPnL %.2f%% → %.2f%%) Fechando %s Qty: %.3f", symbol, status.MaxPnL, pnl, status.Side, qty)
+	w.status = nil
+	if err := w.protector.Cancel(symbol); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+	w.store.RemovePosition(symbol)
+	w.riskEngine.UpdatePosition(symbol, 0)
+	telegram.SendMessage(msg)
+	logger.LogTrade(symbol, "TRAILING-CLOSE", qty, currentPrice, w.coord.Available())
+	return 0
+}
+
+// Intervalo entre tentativas de recriar um stop ausente
+const stopRetryInterval = time.Minute
+
+// ensureStop coloca o stop na corretora para a posição aberta que está sem
+// ele: adotada ou restaurada sem proteção no startup, ou que perdeu o stop ao
+// movê-lo. Com outras proteções já registradas só o stop é recriado.
+func (w *worker) ensureStop(rules binance.SymbolRules, tc config.TrailingConfig, side string, entryPrice, qty float64) {
+	if w.protector.HasStop(w.symbol) || time.Now().Before(w.stopRetry) {
+		return
+	}
+	w.stopRetry = time.Now().Add(stopRetryInterval)
+	log.Printf("🛡️ %s: posição %s sem stop na corretora, protegendo", w.symbol, side)
+	if _, ok := w.protector.Get(w.symbol); !ok {
+		w.protect(rules, tc, side, entryPrice, qty)
+	} else {
+		stopPnL := tc.StopLossPnL
+		if tc.Mode == trailing.ModeLocal {
+			stopPnL = w.status.StopPnL(tc)
+		}
+		stopPrice := rules.RoundPrice(trailing.PriceForPnL(side, entryPrice, w.leverage, stopPnL))
+		if err := w.protector.UpdateStop(w.symbol, stopPrice); err != nil {
+			msg := fmt.Sprintf("⚠️ %s: posição sem stop na corretora: %v", w.symbol, err)
+			log.Println(msg)
+			telegram.SendMessage(msg)
+		}
+	}
+	w.saveProtection()
+}
+
+// evaluate avalia a estratégia no candle que acabou de fechar e abre a
+// posição se houver sinal
+func (w *worker) evaluate(bar engine.Bar) time.Duration {
+	symbol, klines := w.symbol, bar.Klines
+	if w.status != nil || w.halted() {
+		return 0
+	}
+	strat := w.strategies.For(symbol)
+	sig := strat.Evaluate(strategy.Context{Symbol: symbol, Interval: bar.Interval, Klines: klines, Higher: bar.Higher})
+	if sig.None() {
+		fmt.Printf("⚪ %s: Nenhum sinal válido (%s, %s)\n", symbol, strat.Name(), bar.Interval)
+		return 0
+	}
+
+	rules, err := w.client.SymbolRules(symbol)
+	if err != nil {
+		return apiBackoff("regras "+symbol, err)
+	}
+	currentPrice, err := w.markPrice(symbol)
+	if err != nil {
+		return apiBackoff("mark price "+symbol, err)
+	}
+	inPosition, _, _, _, _, err := getPositionInfo(w.client, symbol, w.leverage)
+	if err != nil {
+		return apiBackoff("posição "+symbol, err)
+	}
+	if inPosition {
+		return 0
+	}
+
+	orderSide := sig.Side
+	// Stop e take profit sugeridos pelo sinal substituem os da configuração
+	// nesta posição, inclusive no dimensionamento pelo risco até o stop
+	stopPnL, tpPnL := sig.ExitPnL(currentPrice, w.leverage)
+	tc := trailing.WithExits(w.cfg.Trailing, stopPnL, tpPnL)
+
+	// Dimensionamento e checagem de risco rodam sob o coordinator: a margem
+	// e a posição ficam reservadas antes de qualquer outro worker dimensionar
+	var orderQty, saldo float64
+	var entryOrder risk.Order
+	err = w.coord.Reserve(symbol, func(available float64) (float64, error) {
+		saldo = available
+		sizer := w.sizers.For(symbol)
+		rawQty, err := sizer.Size(risk.SizingInput{
+			Symbol:       symbol,
+			Balance:      available,
+			Price:        currentPrice,
+			Leverage:     w.leverage,
+			StopDistance: currentPrice * math.Abs(tc.StopLossPnL) / 100 / w.leverage,
+			Klines:       klines,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("erro no dimensionamento (%s) de %s: %w", sizer.Name(), symbol, err)
+		}
+		orderQty = rules.RoundQuantity(rawQty, true)
+		if err := rules.Validate(orderQty, 0, currentPrice, true); err != nil {
+			return 0, fmt.Errorf("quantidade inválida: %w", err)
+		}
+		entryOrder = risk.Order{Symbol: symbol, Side: orderSide, Quantity: orderQty, Price: currentPrice, Leverage: w.leverage}
+		if err := w.riskEngine.Check(entryOrder); err != nil {
+			return 0, err
+		}
+		w.riskEngine.UpdatePosition(symbol, entryOrder.Notional())
+		return entryOrder.Notional() / w.leverage, nil
+	})
+	if err != nil {
+		w.riskEngine.UpdatePosition(symbol, 0)
+		var rej *risk.Rejection
+		if errors.As(err, &rej) {
+			notifyRejection(err)
+		} else {
+			log.Printf("❌ %v", err)
+		}
+		return 0
+	}
+
+	msg := fmt.Sprintf("🟢 %s %s | qty %.3f | alav %.0fx | candle %s | %s %.0f%%", orderSide, symbol, orderQty, w.leverage, bar.Interval, strat.Name(), sig.Confidence*100)
+	fmt.Println(msg)
+	if _, err := w.client.PlaceMarketOrder(symbol, orderSide, orderQty, false); err != nil {
+		w.coord.Release(symbol)
+		w.riskEngine.UpdatePosition(symbol, 0)
+		if binance.IsInsufficientMargin(err) {
+			telegram.SendMessage(fmt.Sprintf("💸 Margem insuficiente para %s %s qty %.3f", orderSide, symbol, orderQty))
+		}
+		return apiBackoff("entrada "+symbol, err)
+	}
+	w.coord.Commit(symbol)
+	w.status = &trailing.Status{Side: orderSide}
+	w.store.SetPosition(symbol, state.Position{
+		Side:          orderSide,
+		EntryPrice:    currentPrice,
+		Quantity:      orderQty,
+		Notional:      entryOrder.Notional(),
+		Interval:      bar.Interval,
+		Strategy:      strat.Name(),
+		OpenedAt:      time.Now(),
+		StopLossPnL:   stopPnL,
+		TakeProfitPnL: tpPnL,
+	})
+	w.protect(rules, tc, orderSide, currentPrice, orderQty)
+	w.saveProtection()
+
+	msgDet := fmt.Sprintf("%s\n\n%s\n🛡️ Stop: %.2f%% | TP: %.2f%% (PnL)\n💰 Preço: %.4f | Quantidade: %.1f | Saldo: %.2f",
+		msg,
+		sig.Describe(),
+		tc.StopLossPnL,
+		tc.TakeProfitPnL,
+		currentPrice,
+		orderQty,
+		saldo)
+	telegram.SendMessage(msgDet)
+	logger.LogTrade(symbol, orderSide, orderQty, currentPrice, saldo)
+	reasons, snapshot := sig.Summary()
+	logger.LogSignal(symbol, strat.Name(), orderSide, sig.Confidence, currentPrice, sig.StopLoss, sig.TakeProfit, reasons, snapshot)
+	return 0
+}
@@ -3,14 +3,126 @@ package config
 import (
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	APIKey    string
-	APISecret string
-	Testnet   bool
+	APIKey        string
+	APISecret     string
+	Testnet       bool
+	RecvWindow    int64 // ms
+	Symbols       []string
+	StateFile     string
+	JournalPrefix string // prefixo dos diários CSV; "paper_" no paper trading
+	Risk          RiskConfig
+	Sizing        SizingConfig
+	Trailing      TrailingConfig
+	Strategy      StrategyConfig
+	Engine        EngineConfig
+	Shutdown      ShutdownConfig
+	Paper         PaperConfig
+}
+
+// RiskConfig define os limites pré-trade e o circuit breaker. Valor zero desativa o limite.
+type RiskConfig struct {
+	MaxNotionalPerSymbol float64
+	MaxTotalExposure     float64
+	MaxOpenPositions     int
+	MaxLeverage          float64
+
+	MaxDailyLoss     float64 // USDT de prejuízo realizado no dia (UTC)
+	MaxDailyLossPct  float64 // % do equity no início do dia
+	MaxDrawdownPct   float64 // % de queda desde o pico de equity
+	FlattenOnTrip    bool
+	BreakerStateFile string
+}
+
+// SizingConfig define o modelo de dimensionamento padrão, os modelos por
+// símbolo e os parâmetros de cada modelo.
+type SizingConfig struct {
+	Default   string
+	PerSymbol map[string]string
+
+	FixedNotional  float64 // USDT por entrada
+	RiskPct        float64 // % do saldo arriscado até o stop
+	StopPct        float64 // distância padrão do stop em % do preço
+	ATRPeriod      int
+	ATRMultiplier  float64
+	KellyFraction  float64
+	KellyWinRate   float64
+	KellyPayoff    float64
+	KellyMinTrades int
+}
+
+// TrailingConfig define os níveis de saída em PnL% alavancado. TakeProfitPnL
+// zero desativa o take profit na corretora. Mode "local" acompanha o trailing
+// no loop; "exchange" usa TRAILING_STOP_MARKET nativo da Binance.
+type TrailingConfig struct {
+	Mode          string
+	ActivatePnL   float64
+	CallbackPnL   float64
+	StopLossPnL   float64
+	TakeProfitPnL float64
+}
+
+// StrategyConfig define a estratégia padrão, as estratégias por símbolo e os
+// parâmetros de cada estratégia (nome → parâmetro → valor). Confirm lista os
+// intervalos maiores cuja tendência precisa concordar com o sinal;
+// ConfirmPerSymbol tem precedência e lista vazia desliga a confirmação.
+type StrategyConfig struct {
+	Default          string
+	PerSymbol        map[string]string
+	Params           map[string]map[string]string
+	Confirm          []string
+	ConfirmPerSymbol map[string][]string
+	ConfirmMA        int // período da média da tendência nos intervalos de confirmação
+}
+
+// ConfirmFor retorna os intervalos de confirmação de um símbolo
+func (c StrategyConfig) ConfirmFor(symbol string) []string {
+	if iv, ok := c.ConfirmPerSymbol[symbol]; ok {
+		return iv
+	}
+	return c.Confirm
+}
+
+// EngineConfig define o intervalo de candle avaliado por símbolo e quantos
+// klines ficam em memória. PerSymbol tem precedência sobre Interval.
+type EngineConfig struct {
+	Interval   string
+	PerSymbol  map[string]string
+	WindowSize int
+}
+
+// IntervalFor retorna o intervalo de candle de um símbolo
+func (c EngineConfig) IntervalFor(symbol string) string {
+	if i, ok := c.PerSymbol[symbol]; ok && i != "" {
+		return i
+	}
+	return c.Interval
+}
+
+// ShutdownConfig define o que fazer com as posições abertas ao receber
+// SIGINT/SIGTERM: "hold" mantém as posições protegidas pelos stops na
+// corretora, "flatten" fecha todas a mercado.
+type ShutdownConfig struct {
+	Mode    string
+	Timeout int // segundos para concluir o desligamento
+}
+
+// PaperConfig define o paper trading: dados de mercado reais da mainnet e
+// execuções numa conta simulada local, gravada em StateFile.
+type PaperConfig struct {
+	Enabled        bool
+	StateFile      string
+	InitialBalance float64 // USDT ao criar a conta simulada
+	TakerFeePct    float64
+	MakerFeePct    float64
+	SlippagePct    float64
 }
 
 func LoadConfig() Config {
@@ -19,9 +131,249 @@ func LoadConfig() Config {
 		log.Println("Arquivo .env não encontrado. Usando variáveis do sistema.")
 	}
 
-	return Config{
-		APIKey:    os.Getenv("BINANCE_API_KEY"),
-		APISecret: os.Getenv("BINANCE_API_SECRET"),
-		Testnet:   os.Getenv("BINANCE_TESTNET") == "true",
+	cfg := Config{
+		APIKey:     os.Getenv("BINANCE_API_KEY"),
+		APISecret:  os.Getenv("BINANCE_API_SECRET"),
+		Testnet:    os.Getenv("BINANCE_TESTNET") != "false", // mainnet só com opt-in explícito
+		RecvWindow: LoadRecvWindow(),
+		Symbols:    LoadSymbols(),
+		StateFile:  LoadStateFile(),
+		Risk:       LoadRiskConfig(),
+		Sizing:     LoadSizingConfig(),
+		Trailing:   LoadTrailingConfig(),
+		Strategy:   LoadStrategyConfig(),
+		Engine:     LoadEngineConfig(),
+		Shutdown:   LoadShutdownConfig(),
+		Paper:      LoadPaperConfig(),
+	}
+	if cfg.Paper.Enabled {
+		cfg.usePaperFiles()
+	}
+	return cfg
+}
+
+// paperPrefix marca os arquivos do paper trading
+const paperPrefix = "paper_"
+
+// usePaperFiles troca o estado do bot, o do circuit breaker e os diários
+// pelos do paper trading, para que a conta simulada nunca leia nem grave os
+// arquivos da conta real
+func (c *Config) usePaperFiles() {
+	c.StateFile = paperPath(c.StateFile)
+	c.Risk.BreakerStateFile = paperPath(c.Risk.BreakerStateFile)
+	c.JournalPrefix = paperPrefix
+}
+
+// paperPath acrescenta paperPrefix ao nome do arquivo, mantendo o diretório
+func paperPath(path string) string {
+	dir, file := filepath.Split(path)
+	if strings.HasPrefix(file, paperPrefix) {
+		return path
+	}
+	return dir + paperPrefix + file
+}
+
+// LoadRecvWindow lê a recvWindow (ms) das requisições assinadas
+func LoadRecvWindow() int64 {
+	return int64(getEnvInt("BINANCE_RECV_WINDOW", 5000))
+}
+
+// LoadStateFile lê o caminho do arquivo de estado do bot (trailing, entradas
+// e proteções abertas)
+func LoadStateFile() string {
+	return getEnv("STATE_FILE", "bot_state.json")
+}
+
+// Símbolos negociados quando SYMBOLS não está definido
+var defaultSymbols = []string{"ETHUSDT", "BTCUSDT", "XRPUSDT", "BNBUSDT", "ADAUSDT", "SOLUSDT", "MATICUSDT", "DOTUSDT", "AVAXUSDT", "LINKUSDT"}
+
+// LoadSymbols lê a lista de símbolos de SYMBOLS ("BTCUSDT,ETHUSDT")
+func LoadSymbols() []string {
+	v := os.Getenv("SYMBOLS")
+	if v == "" {
+		return defaultSymbols
+	}
+	var symbols []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.ToUpper(strings.TrimSpace(s)); s != "" {
+			symbols = append(symbols, s)
+		}
+	}
+	return symbols
+}
+
+// LoadRiskConfig lê os limites de risco das variáveis de ambiente.
+func LoadRiskConfig() RiskConfig {
+	return RiskConfig{
+		MaxNotionalPerSymbol: getEnvFloat("RISK_MAX_NOTIONAL_PER_SYMBOL", 0),
+		MaxTotalExposure:     getEnvFloat("RISK_MAX_TOTAL_EXPOSURE", 0),
+		MaxOpenPositions:     getEnvInt("RISK_MAX_OPEN_POSITIONS", 3),
+		MaxLeverage:          getEnvFloat("RISK_MAX_LEVERAGE", 20),
+
+		MaxDailyLoss:     getEnvFloat("RISK_MAX_DAILY_LOSS", 0),
+		MaxDailyLossPct:  getEnvFloat("RISK_MAX_DAILY_LOSS_PCT", 10),
+		MaxDrawdownPct:   getEnvFloat("RISK_MAX_DRAWDOWN_PCT", 25),
+		FlattenOnTrip:    os.Getenv("RISK_FLATTEN_ON_TRIP") == "true",
+		BreakerStateFile: getEnv("RISK_BREAKER_STATE_FILE", "breaker_state.json"),
+	}
+}
+
+// LoadSizingConfig lê o dimensionamento. SIZER_PER_SYMBOL tem o formato
+// "BTCUSDT=atr,ETHUSDT=kelly".
+func LoadSizingConfig() SizingConfig {
+	return SizingConfig{
+		Default:        getEnv("SIZER", "fixed_fractional"),
+		PerSymbol:      getEnvMap("SIZER_PER_SYMBOL"),
+		FixedNotional:  getEnvFloat("SIZER_FIXED_NOTIONAL", 100),
+		RiskPct:        getEnvFloat("SIZER_RISK_PCT", 1),
+		StopPct:        getEnvFloat("SIZER_STOP_PCT", 0.25),
+		ATRPeriod:      getEnvInt("SIZER_ATR_PERIOD", 14),
+		ATRMultiplier:  getEnvFloat("SIZER_ATR_MULTIPLIER", 2),
+		KellyFraction:  getEnvFloat("SIZER_KELLY_FRACTION", 0.25),
+		KellyWinRate:   getEnvFloat("SIZER_KELLY_WIN_RATE", 0.5),
+		KellyPayoff:    getEnvFloat("SIZER_KELLY_PAYOFF", 1.5),
+		KellyMinTrades: getEnvInt("SIZER_KELLY_MIN_TRADES", 20),
+	}
+}
+
+// LoadTrailingConfig lê os níveis de trailing, stop loss e take profit. Um
+// TRAILING_MODE desconhecido desligaria os dois trailings, então volta para
+// "local".
+func LoadTrailingConfig() TrailingConfig {
+	mode := getEnv("TRAILING_MODE", "local")
+	if mode != "local" && mode != "exchange" {
+		log.Printf("⚠️ Valor inválido para TRAILING_MODE (%q), usando local", mode)
+		mode = "local"
+	}
+	return TrailingConfig{
+		Mode:          mode,
+		ActivatePnL:   getEnvFloat("TRAILING_ACTIVATE_PNL", 3),
+		CallbackPnL:   getEnvFloat("TRAILING_CALLBACK_PNL", 1),
+		StopLossPnL:   getEnvFloat("STOP_LOSS_PNL", -5),
+		TakeProfitPnL: getEnvFloat("TAKE_PROFIT_PNL", 20),
+	}
+}
+
+// LoadStrategyConfig lê as estratégias. STRATEGY_PER_SYMBOL tem o formato
+// "BTCUSDT=rsi_macd,ETHUSDT=rsi_reversion" e STRATEGY_PARAMS o formato
+// "rsi_macd.rsi_period=14,rsi_reversion.oversold=25". CONFIRM_INTERVALS tem o
+// formato "15m,1h" e CONFIRM_PER_SYMBOL o formato "BTCUSDT=15m|1h,ETHUSDT=none".
+func LoadStrategyConfig() StrategyConfig {
+	params := make(map[string]map[string]string)
+	for _, pair := range strings.Split(os.Getenv("STRATEGY_PARAMS"), ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		name, param, dotted := strings.Cut(strings.ToLower(strings.TrimSpace(k)), ".")
+		if !ok || !dotted {
+			continue
+		}
+		if params[name] == nil {
+			params[name] = make(map[string]string)
+		}
+		params[name][param] = strings.TrimSpace(v)
+	}
+	confirmPerSymbol := make(map[string][]string)
+	for symbol, v := range getEnvMap("CONFIRM_PER_SYMBOL") {
+		confirmPerSymbol[symbol] = splitIntervals(v, "|")
+	}
+	return StrategyConfig{
+		Default:          getEnv("STRATEGY", "rsi_macd"),
+		PerSymbol:        getEnvMap("STRATEGY_PER_SYMBOL"),
+		Params:           params,
+		Confirm:          splitIntervals(os.Getenv("CONFIRM_INTERVALS"), ","),
+		ConfirmPerSymbol: confirmPerSymbol,
+		ConfirmMA:        getEnvInt("CONFIRM_MA", 50),
+	}
+}
+
+// splitIntervals separa uma lista de intervalos; "none" é a lista vazia
+func splitIntervals(v, sep string) []string {
+	var out []string
+	for _, iv := range strings.Split(v, sep) {
+		if iv = strings.TrimSpace(iv); iv != "" && iv != "none" {
+			out = append(out, iv)
+		}
+	}
+	return out
+}
+
+// LoadEngineConfig lê os intervalos de candle. INTERVAL_PER_SYMBOL tem o
+// formato "BTCUSDT=5m,ETHUSDT=15m".
+func LoadEngineConfig() EngineConfig {
+	return EngineConfig{
+		Interval:   getEnv("INTERVAL", "1m"),
+		PerSymbol:  getEnvMap("INTERVAL_PER_SYMBOL"),
+		WindowSize: getEnvInt("KLINE_WINDOW", 100),
+	}
+}
+
+// LoadShutdownConfig lê o comportamento no desligamento. Um SHUTDOWN_MODE
+// desconhecido volta para "hold" com aviso, em vez de ser tratado como hold
+// em silêncio.
+func LoadShutdownConfig() ShutdownConfig {
+	mode := getEnv("SHUTDOWN_MODE", "hold")
+	if mode != "hold" && mode != "flatten" {
+		log.Printf("⚠️ Valor inválido para SHUTDOWN_MODE (%q), usando hold", mode)
+		mode = "hold"
+	}
+	return ShutdownConfig{
+		Mode:    mode,
+		Timeout: getEnvInt("SHUTDOWN_TIMEOUT", 30),
+	}
+}
+
+// LoadPaperConfig lê o modo paper trading (PAPER_TRADING=true)
+func LoadPaperConfig() PaperConfig {
+	return PaperConfig{
+		Enabled:        os.Getenv("PAPER_TRADING") == "true",
+		StateFile:      getEnv("PAPER_STATE_FILE", "paper_state.json"),
+		InitialBalance: getEnvFloat("PAPER_BALANCE", 1000),
+		TakerFeePct:    getEnvFloat("PAPER_TAKER_FEE", 0.05),
+		MakerFeePct:    getEnvFloat("PAPER_MAKER_FEE", 0.02),
+		SlippagePct:    getEnvFloat("PAPER_SLIPPAGE", 0.01),
+	}
+}
+
+func getEnvMap(key string) map[string]string {
+	m := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		m[strings.ToUpper(strings.TrimSpace(k))] = strings.TrimSpace(v)
+	}
+	return m
+}
+
+func getEnv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func getEnvFloat(key string, def float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Printf("⚠️ Valor inválido para %s (%q), usando %v", key, v, def)
+		return def
+	}
+	return f
+}
+
+func getEnvInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("⚠️ Valor inválido para %s (%q), usando %v", key, v, def)
+		return def
 	}
+	return i
 }
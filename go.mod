@@ -2,4 +2,7 @@ module binance-bot
 
 go 1.24.4
 
-require github.com/joho/godotenv v1.5.1
+require (
+	github.com/gorilla/websocket v1.5.3
+	github.com/joho/godotenv v1.5.1
+)
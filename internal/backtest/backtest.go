@@ -0,0 +1,364 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"binance-bot/config"
+	"binance-bot/internal/indicators"
+	"binance-bot/internal/metrics"
+	"binance-bot/internal/risk"
+	"binance-bot/internal/sim"
+	"binance-bot/internal/strategy"
+	"binance-bot/internal/trailing"
+	"binance-bot/internal/types"
+)
+
+// Config define a simulação. FeePct e SlippagePct são aplicados em cada
+// execução (entrada e saída), em % do preço/notional, com o mesmo modelo de
+// execução da conta simulada (internal/sim).
+type Config struct {
+	Symbol         string
+	InitialBalance float64
+	Leverage       float64
+	FeePct         float64
+	SlippagePct    float64
+	Interval       string // intervalo dos klines, base dos intervalos de confirmação
+	Window         int    // klines passados à estratégia a cada candle
+	Trailing       config.TrailingConfig
+	Sizer          risk.Sizer
+	Strategy       strategy.Strategy
+}
+
+// Trade é uma operação simulada completa
+type Trade struct {
+	Symbol     string
+	Side       string
+	EntryTime  time.Time
+	ExitTime   time.Time
+	EntryPrice float64
+	ExitPrice  float64
+	Quantity   float64
+	Fees       float64
+	PnL        float64 // líquido de taxas
+	Reason     string  // STOP_LOSS, TRAILING, TAKE_PROFIT, END
+}
+
+// EquityPoint é o equity (saldo + PnL não realizado) no fechamento de um candle
+type EquityPoint struct {
+	Time   time.Time
+	Equity float64
+}
+
+// Result é o resultado de um backtest
+type Result struct {
+	Trades       []Trade
+	Equity       []EquityPoint
+	FinalBalance float64
+}
+
+// position é a posição simulada aberta. stopLossPnL e takeProfitPnL são os
+// níveis sugeridos pelo sinal de entrada (zero usa a configuração).
+type position struct {
+	side          string
+	entryPrice    float64
+	qty           float64
+	entryTime     time.Time
+	entryFee      float64
+	stopLossPnL   float64
+	takeProfitPnL float64
+	status        *trailing.Status
+}
+
+// Run percorre os klines candle a candle. Em cada candle fechado sem posição a
+// estratégia é avaliada com a mesma janela usada ao vivo e a entrada é feita no
+// fechamento. Com posição, o stop (fixo ou do trailing) e o take profit são
+// testados contra a mínima/máxima do candle, como as ordens na corretora, e o
+// trailing local é atualizado com o PnL do fechamento.
+func Run(klines []types.Kline, cfg Config) (Result, error) {
+	if cfg.Sizer == nil {
+		return Result{}, errors.New("backtest sem sizer")
+	}
+	if cfg.Strategy == nil {
+		return Result{}, errors.New("backtest sem estratégia")
+	}
+	if cfg.Leverage <= 0 {
+		return Result{}, errors.New("alavancagem deve ser positiva")
+	}
+	window := cfg.Window
+	if window <= 0 {
+		window = 100
+	}
+	window = max(window, cfg.Strategy.Lookback())
+	higher, err := resampleTimeframes(klines, cfg)
+	if err != nil {
+		return Result{}, err
+	}
+	higherWindow := window
+	if m, ok := cfg.Strategy.(strategy.MultiTimeframe); ok {
+		higherWindow = max(window, m.TimeframeLookback())
+	}
+
+	res := Result{FinalBalance: cfg.InitialBalance}
+	balance := cfg.InitialBalance
+	var pos *position
+
+	closePos := func(k types.Kline, price float64, reason string) {
+		exit := sim.MarketPrice(price, trailing.CloseSide(pos.side), cfg.SlippagePct)
+		fee := sim.Fee(exit, pos.qty, cfg.FeePct)
+		gross := sim.PnL(pos.side, pos.entryPrice, exit, pos.qty)
+		pnl := gross - pos.entryFee - fee
+		balance += gross - fee
+		res.Trades = append(res.Trades, Trade{
+			Symbol:     cfg.Symbol,
+			Side:       pos.side,
+			EntryTime:  pos.entryTime,
+			ExitTime:   barTime(k),
+			EntryPrice: pos.entryPrice,
+			ExitPrice:  exit,
+			Quantity:   pos.qty,
+			Fees:       pos.entryFee + fee,
+			PnL:        pnl,
+			Reason:     reason,
+		})
+		if r, ok := cfg.Sizer.(interface{ Record(float64) }); ok {
+			r.Record(pnl)
+		}
+		pos = nil
+	}
+
+	for i, k := range klines {
+		exited := false
+		if pos != nil {
+			exited = manage(pos, k, cfg, closePos)
+		}
+
+		if pos == nil && !exited {
+			from := max(0, i+1-window)
+			bar := klines[from : i+1]
+			sig := cfg.Strategy.Evaluate(strategy.Context{Symbol: cfg.Symbol, Interval: cfg.Interval, Klines: bar, Higher: higher.at(barTime(k), higherWindow)})
+			if !sig.None() {
+				side := sig.Side
+				stopPnL, tpPnL := sig.ExitPnL(k.Close, cfg.Leverage)
+				tc := trailing.WithExits(cfg.Trailing, stopPnL, tpPnL)
+				qty, err := cfg.Sizer.Size(risk.SizingInput{
+					Symbol:       cfg.Symbol,
+					Balance:      balance,
+					Price:        k.Close,
+					Leverage:     cfg.Leverage,
+					StopDistance: k.Close * math.Abs(tc.StopLossPnL) / 100 / cfg.Leverage,
+					Klines:       bar,
+				})
+				if err == nil && qty > 0 {
+					entry := sim.MarketPrice(k.Close, side, cfg.SlippagePct)
+					fee := sim.Fee(entry, qty, cfg.FeePct)
+					balance -= fee
+					pos = &position{
+						side:          side,
+						entryPrice:    entry,
+						qty:           qty,
+						entryTime:     barTime(k),
+						entryFee:      fee,
+						stopLossPnL:   stopPnL,
+						takeProfitPnL: tpPnL,
+						status:        &trailing.Status{Side: side},
+					}
+				}
+			}
+		}
+
+		equity := balance
+		if pos != nil {
+			equity += sim.PnL(pos.side, pos.entryPrice, k.Close, pos.qty)
+		}
+		res.Equity = append(res.Equity, EquityPoint{Time: barTime(k), Equity: equity})
+	}
+
+	if pos != nil {
+		closePos(klines[len(klines)-1], klines[len(klines)-1].Close, "END")
+		res.Equity[len(res.Equity)-1].Equity = balance
+	}
+	res.FinalBalance = balance
+	return res, nil
+}
+
+// timeframes guarda os klines agregados de cada intervalo de confirmação
+type timeframes map[string][]types.Kline
+
+// resampleTimeframes agrega os klines nos intervalos extras pedidos pela
+// estratégia, no lugar dos klines que o bot assina ao vivo
+func resampleTimeframes(klines []types.Kline, cfg Config) (timeframes, error) {
+	intervals := strategy.Timeframes(cfg.Strategy)
+	if len(intervals) == 0 {
+		return nil, nil
+	}
+	if cfg.Interval == "" {
+		return nil, fmt.Errorf("intervalo dos klines necessário para confirmar em %v", intervals)
+	}
+	out := make(timeframes, len(intervals))
+	for _, interval := range intervals {
+		bars, err := indicators.Resample(klines, cfg.Interval, interval)
+		if err != nil {
+			return nil, err
+		}
+		out[interval] = bars
+	}
+	return out, nil
+}
+
+// at retorna, por intervalo, os últimos window klines agregados já fechados
+// em t, como a estratégia os veria ao vivo
+func (tf timeframes) at(t time.Time, window int) map[string][]types.Kline {
+	if tf == nil {
+		return nil
+	}
+	out := make(map[string][]types.Kline, len(tf))
+	for interval, bars := range tf {
+		end := sort.Search(len(bars), func(i int) bool { return bars[i].CloseTime > t.UnixMilli() })
+		out[interval] = bars[max(0, end-window):end]
+	}
+	return out
+}
+
+// manage aplica stop, take profit e trailing a um candle. Retorna true se a
+// posição foi fechada.
+func manage(pos *position, k types.Kline, cfg Config, closePos func(types.Kline, float64, string)) bool {
+	tc := trailing.WithExits(cfg.Trailing, pos.stopLossPnL, pos.takeProfitPnL)
+	adverse, favorable := k.Low, k.High
+	if pos.side == "SELL" {
+		adverse, favorable = k.High, k.Low
+	}
+
+	// Stop na corretora: o fixo ou o do trailing, conforme o pico até o candle anterior
+	stopPrice := trailing.PriceForPnL(pos.side, pos.entryPrice, cfg.Leverage, pos.status.StopPnL(tc))
+	if crossed(pos.side, adverse, stopPrice, false) {
+		reason := "STOP_LOSS"
+		if pos.status.Active(tc) {
+			reason = "TRAILING"
+		}
+		// Se o candle abriu além do stop, a execução é na abertura
+		fill := stopPrice
+		if crossed(pos.side, k.Open, stopPrice, false) {
+			fill = k.Open
+		}
+		closePos(k, fill, reason)
+		return true
+	}
+	if tc.TakeProfitPnL > 0 {
+		tpPrice := trailing.PriceForPnL(pos.side, pos.entryPrice, cfg.Leverage, tc.TakeProfitPnL)
+		if crossed(pos.side, favorable, tpPrice, true) {
+			closePos(k, tpPrice, "TAKE_PROFIT")
+			return true
+		}
+	}
+
+	// O pico acompanha o melhor preço do candle, como o mark price ao vivo
+	pos.status.Update(pnlAt(pos, favorable, cfg.Leverage), tc)
+	if pos.status.Update(pnlAt(pos, k.Close, cfg.Leverage), tc) {
+		reason := "STOP_LOSS"
+		if pos.status.Active(tc) {
+			reason = "TRAILING"
+		}
+		closePos(k, k.Close, reason)
+		return true
+	}
+	return false
+}
+
+// crossed informa se price atingiu level. favorable indica um alvo de lucro
+// (acima para compra); caso contrário é um stop (abaixo para compra).
+func crossed(side string, price, level float64, favorable bool) bool {
+	up := (side == "BUY") == favorable
+	if up {
+		return price >= level
+	}
+	return price <= level
+}
+
+// pnlAt retorna o PnL% alavancado da posição ao preço informado
+func pnlAt(pos *position, price, leverage float64) float64 {
+	move := (price - pos.entryPrice) / pos.entryPrice
+	if pos.side == "SELL" {
+		move = -move
+	}
+	return move * leverage * 100
+}
+
+func barTime(k types.Kline) time.Time {
+	if k.CloseTime > 0 {
+		return time.UnixMilli(k.CloseTime).UTC()
+	}
+	return time.UnixMilli(k.OpenTime).UTC()
+}
+
+// WriteTrades grava a lista de trades em CSV
+func WriteTrades(w io.Writer, trades []Trade) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"symbol", "side", "entry_time", "exit_time", "entry_price", "exit_price", "quantity", "fees", "pnl", "reason"})
+	for _, t := range trades {
+		cw.Write([]string{
+			t.Symbol,
+			t.Side,
+			t.EntryTime.Format(time.RFC3339),
+			t.ExitTime.Format(time.RFC3339),
+			formatFloat(t.EntryPrice),
+			formatFloat(t.ExitPrice),
+			formatFloat(t.Quantity),
+			formatFloat(t.Fees),
+			formatFloat(t.PnL),
+			t.Reason,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteEquity grava a curva de equity em CSV
+func WriteEquity(w io.Writer, curve []EquityPoint) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"time", "equity"})
+	for _, p := range curve {
+		cw.Write([]string{p.Time.Format(time.RFC3339), formatFloat(p.Equity)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 6, 64)
+}
+
+// Summary resume o resultado em uma linha
+func (r Result) Summary(initial float64) string {
+	wins := 0
+	for _, t := range r.Trades {
+		if t.PnL > 0 {
+			wins++
+		}
+	}
+	winRate := 0.0
+	if len(r.Trades) > 0 {
+		winRate = float64(wins) / float64(len(r.Trades)) * 100
+	}
+	return fmt.Sprintf("Trades: %d | Acertos: %.1f%% | Saldo: %.2f → %.2f (%+.2f%%)",
+		len(r.Trades), winRate, initial, r.FinalBalance, (r.FinalBalance/initial-1)*100)
+}
+
+// Metrics calcula as métricas de desempenho do resultado
+func (r Result) Metrics(initial float64) metrics.Report {
+	trades := make([]metrics.Trade, len(r.Trades))
+	for i, t := range r.Trades {
+		trades[i] = metrics.Trade{Symbol: t.Symbol, Side: t.Side, EntryTime: t.EntryTime, ExitTime: t.ExitTime, PnL: t.PnL}
+	}
+	equity := make([]metrics.Point, len(r.Equity))
+	for i, p := range r.Equity {
+		equity[i] = metrics.Point{Time: p.Time, Equity: p.Equity}
+	}
+	return metrics.Compute(trades, equity, initial)
+}
@@ -0,0 +1,200 @@
+// internal/backtest/backtest_test.go
+package backtest
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"binance-bot/config"
+	"binance-bot/internal/risk"
+	"binance-bot/internal/sim"
+	"binance-bot/internal/strategy"
+	"binance-bot/internal/trailing"
+	"binance-bot/internal/types"
+)
+
+var testTrailing = config.TrailingConfig{Mode: trailing.ModeLocal, ActivatePnL: 3, CallbackPnL: 1, StopLossPnL: -5, TakeProfitPnL: 20}
+
+func testPosition() *position {
+	return &position{side: "BUY", entryPrice: 100, qty: 1, status: &trailing.Status{Side: "BUY"}}
+}
+
+func TestManageStopLoss(t *testing.T) {
+	cfg := Config{Leverage: 10, Trailing: testTrailing}
+	var reason string
+	var fill float64
+	closePos := func(k types.Kline, price float64, r string) { fill, reason = price, r }
+
+	// Stop em -5% de PnL com 10x = 0.5% abaixo da entrada
+	if !manage(testPosition(), types.Kline{Open: 100, High: 100.2, Low: 99.4, Close: 99.8}, cfg, closePos) {
+		t.Fatal("stop deveria ter sido executado")
+	}
+	if reason != "STOP_LOSS" || math.Abs(fill-99.5) > 1e-9 {
+		t.Errorf("saída = %s @ %v; want STOP_LOSS @ 99.5", reason, fill)
+	}
+
+	// Abertura com gap além do stop executa na abertura
+	manage(testPosition(), types.Kline{Open: 99, High: 99.2, Low: 98.8, Close: 99}, cfg, closePos)
+	if fill != 99 {
+		t.Errorf("gap: saída @ %v; want 99", fill)
+	}
+}
+
+func TestManageSignalExits(t *testing.T) {
+	cfg := Config{Leverage: 10, Trailing: testTrailing}
+	var reason string
+	var fill float64
+	closePos := func(k types.Kline, price float64, r string) { fill, reason = price, r }
+
+	// Stop sugerido pelo sinal em -10% (99) no lugar dos -5% da configuração
+	pos := testPosition()
+	pos.stopLossPnL, pos.takeProfitPnL = -10, 15
+	if manage(pos, types.Kline{Open: 100, High: 100.2, Low: 99.4, Close: 99.8}, cfg, closePos) {
+		t.Fatal("stop da configuração não deveria valer para a posição")
+	}
+	if !manage(pos, types.Kline{Open: 99.8, High: 101.6, Low: 99.8, Close: 101}, cfg, closePos) || reason != "TAKE_PROFIT" || math.Abs(fill-101.5) > 1e-9 {
+		t.Errorf("saída = %s @ %v; want TAKE_PROFIT @ 101.5", reason, fill)
+	}
+}
+
+func TestManageTrailing(t *testing.T) {
+	cfg := Config{Leverage: 10, Trailing: testTrailing}
+	var reason string
+	closePos := func(k types.Kline, price float64, r string) { reason = r }
+	pos := testPosition()
+
+	// Máxima leva o PnL a 6% (ativa o trailing); fechamento em 5.5% ainda segura
+	if manage(pos, types.Kline{Open: 100, High: 100.6, Low: 100, Close: 100.55}, cfg, closePos) {
+		t.Fatal("não deveria sair com PnL dentro do callback")
+	}
+	if pos.status.MaxPnL < 6-1e-9 {
+		t.Errorf("MaxPnL = %v; want 6", pos.status.MaxPnL)
+	}
+	// Próximo candle toca o stop do trailing (5% de PnL = 100.5)
+	if !manage(pos, types.Kline{Open: 100.55, High: 100.56, Low: 100.4, Close: 100.45}, cfg, closePos) || reason != "TRAILING" {
+		t.Errorf("saída = %q; want TRAILING", reason)
+	}
+}
+
+func TestRunNoSignal(t *testing.T) {
+	var klines []types.Kline
+	for i := 0; i < 50; i++ {
+		klines = append(klines, types.Kline{OpenTime: int64(i) * 60000, Open: 100, High: 100, Low: 100, Close: 100, Volume: 1, CloseTime: int64(i)*60000 + 59999})
+	}
+	strat, err := strategy.New("rsi_macd", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := Run(klines, Config{Symbol: "BTCUSDT", InitialBalance: 1000, Leverage: 10, Trailing: testTrailing, Sizer: &risk.FixedNotional{Notional: 100}, Strategy: strat})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Trades) != 0 || res.FinalBalance != 1000 || len(res.Equity) != 50 {
+		t.Errorf("Result = %d trades, saldo %v, %d pontos", len(res.Trades), res.FinalBalance, len(res.Equity))
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	data := "open_time,open,high,low,close,volume,close_time\n" +
+		"1700000000000,100,101,99,100.5,12.5,1700000059999\n" +
+		"1700000060000,100.5,102,100,101,8,1700000119999\n"
+	klines, err := ReadCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := types.Kline{OpenTime: 1700000060000, Open: 100.5, High: 102, Low: 100, Close: 101, Volume: 8, CloseTime: 1700000119999}
+	if len(klines) != 2 || klines[1] != want {
+		t.Errorf("klines = %+v", klines)
+	}
+	if _, err := ReadCSV(strings.NewReader("")); err == nil {
+		t.Error("arquivo vazio deveria retornar erro")
+	}
+}
+
+// confirmacao registra os klines de 5m que a estratégia recebe a cada candle
+type confirmacao struct{ vistos []int }
+
+func (c *confirmacao) Name() string           { return "confirmacao" }
+func (c *confirmacao) Lookback() int          { return 1 }
+func (c *confirmacao) Timeframes() []string   { return []string{"5m"} }
+func (c *confirmacao) TimeframeLookback() int { return 1 }
+
+func (c *confirmacao) Evaluate(ctx strategy.Context) strategy.Signal {
+	higher := ctx.Higher["5m"]
+	if n := len(higher); n > 0 && higher[n-1].CloseTime > ctx.Klines[len(ctx.Klines)-1].CloseTime {
+		panic("candle de 5m ainda aberto entregue à estratégia")
+	}
+	c.vistos = append(c.vistos, len(higher))
+	return strategy.Signal{}
+}
+
+func TestRunHigherTimeframes(t *testing.T) {
+	var klines []types.Kline
+	for i := 0; i < 12; i++ {
+		klines = append(klines, types.Kline{OpenTime: int64(i) * 60000, Open: 100, High: 100, Low: 100, Close: 100, Volume: 1, CloseTime: int64(i)*60000 + 59999})
+	}
+	strat := &confirmacao{}
+	cfg := Config{Symbol: "BTCUSDT", InitialBalance: 1000, Leverage: 10, Interval: "1m", Trailing: testTrailing, Sizer: &risk.FixedNotional{Notional: 100}, Strategy: strat}
+	if _, err := Run(klines, cfg); err != nil {
+		t.Fatal(err)
+	}
+	// O primeiro 5m fecha no 5º candle de 1m e o segundo no 10º
+	want := []int{0, 0, 0, 0, 1, 1, 1, 1, 1, 2, 2, 2}
+	if !reflect.DeepEqual(strat.vistos, want) {
+		t.Errorf("klines de 5m por candle = %v; want %v", strat.vistos, want)
+	}
+
+	cfg.Interval = ""
+	if _, err := Run(klines, cfg); err == nil {
+		t.Error("confirmação sem o intervalo dos klines deveria falhar")
+	}
+}
+
+// sempreCompra compra em todo candle sem posição
+type sempreCompra struct{}
+
+func (sempreCompra) Name() string  { return "sempre_compra" }
+func (sempreCompra) Lookback() int { return 1 }
+func (sempreCompra) Evaluate(strategy.Context) strategy.Signal {
+	return strategy.Signal{Side: "BUY"}
+}
+
+func TestRunMatchesSimExchange(t *testing.T) {
+	closes := []float64{100, 100.1, 100.05, 100.2}
+	var klines []types.Kline
+	for i, c := range closes {
+		klines = append(klines, types.Kline{OpenTime: int64(i) * 60000, Open: c, High: c, Low: c, Close: c, Volume: 1, CloseTime: int64(i)*60000 + 59999})
+	}
+	cfg := Config{Symbol: "BTCUSDT", InitialBalance: 1000, Leverage: 10, FeePct: 0.05, SlippagePct: 0.01,
+		Trailing: testTrailing, Sizer: &risk.FixedNotional{Notional: 100}, Strategy: sempreCompra{}}
+	res, err := Run(klines, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Trades) != 1 || res.Trades[0].Reason != "END" {
+		t.Fatalf("Trades = %+v; want uma posição encerrada no fim", res.Trades)
+	}
+	trade := res.Trades[0]
+
+	// As mesmas execuções na conta simulada dão os mesmos preços, taxas e PnL
+	e := sim.New(sim.Config{InitialBalance: 1000, Leverage: 10, TakerFeePct: cfg.FeePct, SlippagePct: cfg.SlippagePct}, nil)
+	at := time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)
+	e.Tick("BTCUSDT", closes[0], at)
+	entry, err := e.PlaceMarketOrder("BTCUSDT", "BUY", trade.Quantity, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Tick("BTCUSDT", closes[len(closes)-1], at.Add(3*time.Minute))
+	exit, err := e.PlaceMarketOrder("BTCUSDT", "SELL", trade.Quantity, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	equity, _ := e.GetAccountEquity()
+	if entry.AvgPrice != trade.EntryPrice || exit.AvgPrice != trade.ExitPrice || math.Abs(equity-1000-trade.PnL) > 1e-9 || math.Abs(res.FinalBalance-equity) > 1e-9 {
+		t.Errorf("backtest %v → %v, PnL %v, saldo %v; sim %v → %v, equity %v",
+			trade.EntryPrice, trade.ExitPrice, trade.PnL, res.FinalBalance, entry.AvgPrice, exit.AvgPrice, equity)
+	}
+}
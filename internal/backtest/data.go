@@ -0,0 +1,76 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"binance-bot/internal/types"
+)
+
+// LoadCSV lê klines de um CSV no formato do histórico da Binance
+// (open_time,open,high,low,close,volume,close_time,...). Uma linha de
+// cabeçalho é ignorada; close_time é opcional.
+func LoadCSV(path string) ([]types.Kline, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadCSV(f)
+}
+
+// ReadCSV lê klines de r no mesmo formato de LoadCSV
+func ReadCSV(r io.Reader) ([]types.Kline, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var klines []types.Kline
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("linha %d: %w", line, err)
+		}
+		if len(record) < 6 {
+			return nil, fmt.Errorf("linha %d: esperado ao menos 6 colunas, encontrado %d", line, len(record))
+		}
+		openTime, err := strconv.ParseInt(record[0], 10, 64)
+		if err != nil {
+			if line == 1 {
+				continue // cabeçalho
+			}
+			return nil, fmt.Errorf("linha %d: open_time inválido %q", line, record[0])
+		}
+		var values [5]float64
+		for i := range values {
+			if values[i], err = strconv.ParseFloat(record[i+1], 64); err != nil {
+				return nil, fmt.Errorf("linha %d: valor inválido %q", line, record[i+1])
+			}
+		}
+		k := types.Kline{
+			OpenTime: openTime,
+			Open:     values[0],
+			High:     values[1],
+			Low:      values[2],
+			Close:    values[3],
+			Volume:   values[4],
+		}
+		if len(record) > 6 {
+			if k.CloseTime, err = strconv.ParseInt(record[6], 10, 64); err != nil {
+				return nil, fmt.Errorf("linha %d: close_time inválido %q", line, record[6])
+			}
+		}
+		klines = append(klines, k)
+	}
+	if len(klines) == 0 {
+		return nil, errors.New("nenhum kline no arquivo")
+	}
+	return klines, nil
+}
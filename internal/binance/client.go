@@ -1,6 +1,7 @@
 package binance
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
@@ -12,27 +13,65 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"binance-bot/config"
 )
 
 type BinanceRestClient struct {
-	APIKey    string
-	APISecret string
-	BaseURL   string
+	APIKey     string
+	APISecret  string
+	BaseURL    string
+	StreamURL  string
+	RecvWindow int64 // ms; zero usa 5000
+
+	exchangeInfo exchangeInfoCache
+	limiter      rateLimiter
+	clock        timeSync
+
+	ctxMu sync.RWMutex
+	ctx   context.Context
 }
 
 func NewBinanceRestClient(cfg config.Config) *BinanceRestClient {
 	base := "https://fapi.binance.com"
+	stream := "wss://fstream.binance.com"
 	if cfg.Testnet {
 		base = "https://testnet.binancefuture.com"
+		stream = "wss://stream.binancefuture.com"
 	}
 	return &BinanceRestClient{
-		APIKey:    cfg.APIKey,
-		APISecret: cfg.APISecret,
-		BaseURL:   base,
+		APIKey:     cfg.APIKey,
+		APISecret:  cfg.APISecret,
+		BaseURL:    base,
+		StreamURL:  stream,
+		RecvWindow: cfg.RecvWindow,
+	}
+}
+
+// SetContext define o contexto das requisições. Consultas (GET) são
+// canceladas junto com ele; requisições que alteram algo na corretora
+// (ordens, cancelamentos, listenKey) sempre vão até o fim, para que nenhuma
+// ordem fique em estado desconhecido no desligamento.
+func (b *BinanceRestClient) SetContext(ctx context.Context) {
+	b.ctxMu.Lock()
+	defer b.ctxMu.Unlock()
+	b.ctx = ctx
+}
+
+// requestContext retorna o contexto de uma requisição com o método informado
+func (b *BinanceRestClient) requestContext(method string) context.Context {
+	b.ctxMu.RLock()
+	ctx := b.ctx
+	b.ctxMu.RUnlock()
+	if ctx == nil {
+		return context.Background()
+	}
+	if method != http.MethodGet {
+		return context.WithoutCancel(ctx)
 	}
+	return ctx
 }
 
 func Sign(data, secret string) string {
@@ -41,105 +80,251 @@ func Sign(data, secret string) string {
 	return hex.EncodeToString(h.Sum(nil))
 }
 
-func (b *BinanceRestClient) PlaceMarketOrder(symbol, side string, quantity float64, reduceOnly bool) bool {
-	endpoint := "/fapi/v1/order"
-	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
-	quantityStr := strconv.FormatFloat(quantity, 'f', -1, 64)
-
-	params := url.Values{}
-	params.Add("symbol", symbol)
-	params.Add("side", side)
-	params.Add("type", "MARKET")
-	params.Add("quantity", quantityStr)
-	params.Add("recvWindow", "5000")
-	params.Add("timestamp", timestamp)
-	if reduceOnly {
-		params.Add("reduceOnly", "true")
+// signedRequest envia uma requisição assinada e retorna o corpo da resposta.
+// Se a Binance recusar o timestamp (-1021), ressincroniza o horário e tenta
+// mais uma vez.
+func (b *BinanceRestClient) signedRequest(method, endpoint string, params url.Values) ([]byte, error) {
+	body, err := b.do(method, endpoint, b.sign(params), true)
+	if !IsTimestampOutsideRecvWindow(err) {
+		return body, err
+	}
+	log.Printf("🕒 Timestamp fora da recvWindow em %s, ressincronizando horário", endpoint)
+	if syncErr := b.SyncTime(); syncErr != nil {
+		log.Printf("⚠️ %v", syncErr)
+		return nil, err
 	}
+	return b.do(method, endpoint, b.sign(params), true)
+}
+
+// sign adiciona recvWindow, timestamp e assinatura aos parâmetros
+func (b *BinanceRestClient) sign(params url.Values) url.Values {
+	params.Del("signature")
+	params.Set("recvWindow", strconv.FormatInt(b.recvWindow(), 10))
+	params.Set("timestamp", strconv.FormatInt(b.timestamp(), 10))
+	params.Set("signature", Sign(params.Encode(), b.APISecret))
+	return params
+}
 
-	signature := Sign(params.Encode(), b.APISecret)
-	params.Add("signature", signature)
+// publicRequest envia uma requisição GET sem assinatura
+func (b *BinanceRestClient) publicRequest(endpoint string, params url.Values) ([]byte, error) {
+	return b.do(http.MethodGet, endpoint, params, false)
+}
 
-	req, err := http.NewRequest("POST", b.BaseURL+endpoint, strings.NewReader(params.Encode()))
+// do executa a requisição e converte respostas de erro em *APIError
+func (b *BinanceRestClient) do(method, endpoint string, params url.Values, withKey bool) ([]byte, error) {
+	ctx := b.requestContext(method)
+	var req *http.Request
+	var err error
+	if method == http.MethodPost || method == http.MethodPut {
+		req, err = http.NewRequestWithContext(ctx, method, b.BaseURL+endpoint, strings.NewReader(params.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	} else {
+		target := b.BaseURL + endpoint
+		if len(params) > 0 {
+			target += "?" + params.Encode()
+		}
+		req, err = http.NewRequestWithContext(ctx, method, target, nil)
+	}
 	if err != nil {
-		log.Println("Erro ao criar requisição:", err)
-		return false
+		return nil, fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+	if withKey {
+		req.Header.Set("X-MBX-APIKEY", b.APIKey)
 	}
-	req.Header.Set("X-MBX-APIKEY", b.APIKey)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
+	if err := b.limiter.wait(ctx, endpointWeight(method, endpoint, params), isOrderRequest(method, endpoint)); err != nil {
+		return nil, fmt.Errorf("requisição %s cancelada: %w", endpoint, err)
+	}
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		log.Println("Erro ao enviar requisição:", err)
-		return false
+		return nil, fmt.Errorf("erro ao enviar requisição %s: %w", endpoint, err)
 	}
 	defer resp.Body.Close()
+	b.limiter.update(time.Now(), resp.StatusCode, resp.Header)
 
-	bodyBytes, _ := io.ReadAll(resp.Body)
-	var result map[string]interface{}
-	json.Unmarshal(bodyBytes, &result)
-
-	if code, ok := result["code"]; ok {
-		log.Printf("📨 Order response: %+v", result)
-		log.Printf("❌ Erro da Binance: code %v, msg: %v", code, result["msg"])
-		return false
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler resposta de %s: %w", endpoint, err)
+	}
+	var apiErr struct {
+		Code *int   `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Code != nil && *apiErr.Code != 200 {
+		return nil, &APIError{StatusCode: resp.StatusCode, Code: *apiErr.Code, Msg: apiErr.Msg}
 	}
-	log.Printf("📨 Ordem executada com sucesso: %+v", result)
-	return true
+	if resp.StatusCode >= 400 {
+		return nil, &APIError{StatusCode: resp.StatusCode, Msg: strings.TrimSpace(string(body))}
+	}
+	return body, nil
 }
 
-func (b *BinanceRestClient) GetUSDTBalance() float64 {
-	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
-	params := "timestamp=" + timestamp
-	signature := Sign(params, b.APISecret)
-	url := b.BaseURL + "/fapi/v2/account?" + params + "&signature=" + signature
+// PlaceMarketOrder envia uma ordem MARKET, opcionalmente reduce-only
+func (b *BinanceRestClient) PlaceMarketOrder(symbol, side string, quantity float64, reduceOnly bool) (*Order, error) {
+	return b.NewOrder(OrderRequest{
+		Symbol:     symbol,
+		Side:       side,
+		Type:       OrderTypeMarket,
+		Quantity:   quantity,
+		ReduceOnly: reduceOnly,
+	})
+}
 
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("X-MBX-APIKEY", b.APIKey)
-	resp, err := http.DefaultClient.Do(req)
+type accountInfo struct {
+	AvailableBalance   string `json:"availableBalance"`
+	TotalMarginBalance string `json:"totalMarginBalance"`
+}
+
+func (b *BinanceRestClient) getAccount() (*accountInfo, error) {
+	body, err := b.signedRequest(http.MethodGet, "/fapi/v2/account", url.Values{})
 	if err != nil {
-		log.Println("Erro ao obter saldo:", err)
-		return 0.0
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&result)
+	var account accountInfo
+	if err := json.Unmarshal(body, &account); err != nil {
+		return nil, fmt.Errorf("resposta de conta inválida: %w", err)
+	}
+	return &account, nil
+}
 
-	if available, ok := result["availableBalance"].(string); ok {
-		balance, _ := strconv.ParseFloat(available, 64)
-		return balance
+// GetUSDTBalance retorna o saldo disponível para novas ordens
+func (b *BinanceRestClient) GetUSDTBalance() (float64, error) {
+	account, err := b.getAccount()
+	if err != nil {
+		return 0, err
+	}
+	balance, err := strconv.ParseFloat(account.AvailableBalance, 64)
+	if err != nil {
+		return 0, fmt.Errorf("availableBalance inválido %q: %w", account.AvailableBalance, err)
 	}
-	return 0.0
+	return balance, nil
 }
 
-func (b *BinanceRestClient) GetMarkPrice(symbol string) float64 {
-	url := b.BaseURL + "/fapi/v1/premiumIndex?symbol=" + symbol
-	resp, err := http.Get(url)
+// GetAccountEquity retorna o saldo de margem total (carteira + PnL não realizado)
+func (b *BinanceRestClient) GetAccountEquity() (float64, error) {
+	account, err := b.getAccount()
 	if err != nil {
-		log.Println("Erro ao obter mark price:", err)
-		return 0.0
+		return 0, err
 	}
-	defer resp.Body.Close()
-	var result map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&result)
-	price, _ := strconv.ParseFloat(result["markPrice"].(string), 64)
-	return price
+	equity, err := strconv.ParseFloat(account.TotalMarginBalance, 64)
+	if err != nil {
+		return 0, fmt.Errorf("totalMarginBalance inválido %q: %w", account.TotalMarginBalance, err)
+	}
+	return equity, nil
 }
 
-func (b *BinanceRestClient) GetKlines(symbol, interval string, limit int) [][]interface{} {
-	url := fmt.Sprintf("%s/fapi/v1/klines?symbol=%s&interval=%s&limit=%d", b.BaseURL, symbol, interval, limit)
-	resp, err := http.Get(url)
+func (b *BinanceRestClient) GetMarkPrice(symbol string) (float64, error) {
+	params := url.Values{}
+	params.Add("symbol", symbol)
+	body, err := b.publicRequest("/fapi/v1/premiumIndex", params)
 	if err != nil {
-		log.Println("Erro ao obter klines:", err)
-		return nil
+		return 0, err
 	}
-	defer resp.Body.Close()
+	var result struct {
+		MarkPrice string `json:"markPrice"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return 0, fmt.Errorf("resposta de mark price inválida: %w", err)
+	}
+	if result.MarkPrice == "" {
+		return 0, fmt.Errorf("markPrice ausente na resposta para %s", symbol)
+	}
+	price, err := strconv.ParseFloat(result.MarkPrice, 64)
+	if err != nil {
+		return 0, fmt.Errorf("markPrice inválido %q: %w", result.MarkPrice, err)
+	}
+	return price, nil
+}
 
+// maxKlinesLimit é o maior limit aceito por /fapi/v1/klines
+const maxKlinesLimit = 1500
+
+// GetKlines retorna os últimos limit klines do intervalo, do mais antigo ao
+// mais recente. Acima de maxKlinesLimit a consulta é feita em páginas,
+// recuando pelo endTime.
+func (b *BinanceRestClient) GetKlines(symbol, interval string, limit int) ([][]interface{}, error) {
 	var klines [][]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&klines); err != nil {
-		log.Println("Error parsing klines JSON:", err)
-		return nil
+	var endTime int64
+	for len(klines) < limit {
+		n := min(limit-len(klines), maxKlinesLimit)
+		params := url.Values{}
+		params.Add("symbol", symbol)
+		params.Add("interval", interval)
+		params.Add("limit", strconv.Itoa(n))
+		if endTime > 0 {
+			params.Add("endTime", strconv.FormatInt(endTime, 10))
+		}
+		body, err := b.publicRequest("/fapi/v1/klines", params)
+		if err != nil {
+			return nil, err
+		}
+		var page [][]interface{}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("erro ao decodificar klines: %w", err)
+		}
+		klines = append(page, klines...)
+		if len(page) < n || len(page[0]) == 0 {
+			break // início do histórico
+		}
+		openTime, ok := page[0][0].(float64)
+		if !ok {
+			return nil, fmt.Errorf("openTime inválido em klines: %v", page[0][0])
+		}
+		endTime = int64(openTime) - 1
+	}
+	return klines, nil
+}
+
+// Position é uma posição como retornada por /fapi/v2/positionRisk
+type Position struct {
+	Symbol           string
+	PositionSide     string
+	PositionAmt      float64
+	EntryPrice       float64
+	MarkPrice        float64
+	UnrealizedProfit float64
+	LiquidationPrice float64
+	Leverage         float64
+}
+
+// GetPositions retorna as posições de um símbolo, ou de todos se symbol for vazio.
+// A Binance inclui símbolos sem posição (PositionAmt zero).
+func (b *BinanceRestClient) GetPositions(symbol string) ([]Position, error) {
+	params := url.Values{}
+	if symbol != "" {
+		params.Add("symbol", symbol)
+	}
+	body, err := b.signedRequest(http.MethodGet, "/fapi/v2/positionRisk", params)
+	if err != nil {
+		return nil, err
+	}
+	var raw []struct {
+		Symbol           string `json:"symbol"`
+		PositionSide     string `json:"positionSide"`
+		PositionAmt      string `json:"positionAmt"`
+		EntryPrice       string `json:"entryPrice"`
+		MarkPrice        string `json:"markPrice"`
+		UnRealizedProfit string `json:"unRealizedProfit"`
+		LiquidationPrice string `json:"liquidationPrice"`
+		Leverage         string `json:"leverage"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("resposta de posições inválida: %w", err)
+	}
+	positions := make([]Position, len(raw))
+	for i, r := range raw {
+		positions[i] = Position{
+			Symbol:           r.Symbol,
+			PositionSide:     r.PositionSide,
+			PositionAmt:      parseFloat(r.PositionAmt),
+			EntryPrice:       parseFloat(r.EntryPrice),
+			MarkPrice:        parseFloat(r.MarkPrice),
+			UnrealizedProfit: parseFloat(r.UnRealizedProfit),
+			LiquidationPrice: parseFloat(r.LiquidationPrice),
+			Leverage:         parseFloat(r.Leverage),
+		}
 	}
-	return klines
+	return positions, nil
 }
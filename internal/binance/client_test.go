@@ -0,0 +1,49 @@
+// internal/binance/client_test.go
+package binance
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGetKlinesPages(t *testing.T) {
+	// Histórico de 2000 candles de 1m; a API devolve no máximo 1500 por vez
+	const total, step = 2000, 60000
+	var limits []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		limits = append(limits, r.URL.Query().Get("limit"))
+		end := total
+		if e := r.URL.Query().Get("endTime"); e != "" {
+			v, _ := strconv.Atoi(e)
+			end = v/step + 1
+		}
+		var rows []string
+		for i := max(end-limit, 0); i < end; i++ {
+			rows = append(rows, fmt.Sprintf(`[%d,"1","1","1","1","1",%d]`, i*step, (i+1)*step-1))
+		}
+		w.Write([]byte("[" + strings.Join(rows, ",") + "]"))
+	}))
+	defer srv.Close()
+	client := &BinanceRestClient{BaseURL: srv.URL}
+
+	klines, err := client.GetKlines("BTCUSDT", "1m", 1800)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(klines) != 1800 || klines[0][0].(float64) != 200*step || klines[1799][0].(float64) != 1999*step {
+		t.Errorf("GetKlines = %d klines de %v a %v; want 1800 de %d a %d", len(klines), klines[0][0], klines[len(klines)-1][0], 200*step, 1999*step)
+	}
+	if strings.Join(limits, ",") != "1500,300" {
+		t.Errorf("limits = %v; want [1500 300]", limits)
+	}
+
+	// Sem histórico suficiente, para no início dele
+	if klines, err = client.GetKlines("BTCUSDT", "1m", 5000); err != nil || len(klines) != total {
+		t.Errorf("GetKlines(5000) = %d klines, %v; want %d", len(klines), err, total)
+	}
+}
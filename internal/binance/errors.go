@@ -0,0 +1,72 @@
+package binance
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Códigos de erro da API de futuros usados pelo bot
+const (
+	CodeTooManyRequests            = -1003
+	CodeTooManyOrders              = -1015
+	CodeTimestampOutsideRecvWindow = -1021
+	CodeUnknownOrder               = -2011
+	CodeNoSuchOrder                = -2013
+	CodeBalanceInsufficient        = -2018
+	CodeMarginInsufficient         = -2019
+)
+
+// APIError é um erro retornado pela Binance (ou uma resposta HTTP de erro sem corpo JSON)
+type APIError struct {
+	StatusCode int
+	Code       int
+	Msg        string
+}
+
+func (e *APIError) Error() string {
+	if e.Code == 0 {
+		return fmt.Sprintf("erro HTTP %d: %s", e.StatusCode, e.Msg)
+	}
+	return fmt.Sprintf("erro da Binance (HTTP %d): code %d, msg: %s", e.StatusCode, e.Code, e.Msg)
+}
+
+func asAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	ok := errors.As(err, &apiErr)
+	return apiErr, ok
+}
+
+// IsRateLimited indica limite de requisições/ordens excedido (HTTP 429/418)
+func IsRateLimited(err error) bool {
+	e, ok := asAPIError(err)
+	if !ok {
+		return false
+	}
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusTeapot ||
+		e.Code == CodeTooManyRequests || e.Code == CodeTooManyOrders
+}
+
+// IsIPBanned indica que o IP foi banido temporariamente (HTTP 418)
+func IsIPBanned(err error) bool {
+	e, ok := asAPIError(err)
+	return ok && e.StatusCode == http.StatusTeapot
+}
+
+// IsInsufficientMargin indica saldo ou margem insuficiente para a ordem
+func IsInsufficientMargin(err error) bool {
+	e, ok := asAPIError(err)
+	return ok && (e.Code == CodeMarginInsufficient || e.Code == CodeBalanceInsufficient)
+}
+
+// IsTimestampOutsideRecvWindow indica relógio local fora da janela aceita (-1021)
+func IsTimestampOutsideRecvWindow(err error) bool {
+	e, ok := asAPIError(err)
+	return ok && e.Code == CodeTimestampOutsideRecvWindow
+}
+
+// IsUnknownOrder indica que a ordem não existe mais (já executada ou cancelada)
+func IsUnknownOrder(err error) bool {
+	e, ok := asAPIError(err)
+	return ok && (e.Code == CodeUnknownOrder || e.Code == CodeNoSuchOrder)
+}
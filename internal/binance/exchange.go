@@ -0,0 +1,24 @@
+package binance
+
+// Exchange é o que o bot usa de uma corretora: conta, dados de mercado,
+// posições e ordens. BinanceRestClient é a implementação real; internal/sim
+// tem uma conta simulada em memória para paper trading, backtests e testes.
+type Exchange interface {
+	GetUSDTBalance() (float64, error)
+	GetAccountEquity() (float64, error)
+	GetMarkPrice(symbol string) (float64, error)
+	GetKlines(symbol, interval string, limit int) ([][]interface{}, error)
+	SymbolRules(symbol string) (SymbolRules, error)
+
+	GetPositions(symbol string) ([]Position, error)
+	GetOpenOrders(symbol string) ([]Order, error)
+
+	PlaceMarketOrder(symbol, side string, quantity float64, reduceOnly bool) (*Order, error)
+	PlaceLimitOrder(symbol, side string, quantity, price float64, timeInForce string) (*Order, error)
+	PlaceStopMarketOrder(symbol, side string, quantity, stopPrice float64) (*Order, error)
+	PlaceTakeProfitMarketOrder(symbol, side string, quantity, stopPrice float64) (*Order, error)
+	PlaceTrailingStopMarketOrder(symbol, side string, quantity, activationPrice, callbackRate float64) (*Order, error)
+	CancelOrder(symbol string, orderID int64) (*Order, error)
+}
+
+var _ Exchange = (*BinanceRestClient)(nil)
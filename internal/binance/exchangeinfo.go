@@ -0,0 +1,240 @@
+package binance
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tempo de vida do cache de exchangeInfo
+const exchangeInfoTTL = time.Hour
+
+// SymbolRules são as regras de negociação de um símbolo (filtros do exchangeInfo)
+type SymbolRules struct {
+	Symbol string
+	Status string
+
+	// PRICE_FILTER
+	TickSize float64
+	MinPrice float64
+	MaxPrice float64
+
+	// LOT_SIZE
+	StepSize float64
+	MinQty   float64
+	MaxQty   float64
+
+	// MARKET_LOT_SIZE
+	MarketStepSize float64
+	MarketMinQty   float64
+	MarketMaxQty   float64
+
+	// MIN_NOTIONAL
+	MinNotional float64
+
+	// PERCENT_PRICE
+	MultiplierUp   float64
+	MultiplierDown float64
+
+	priceDecimals     int
+	qtyDecimals       int
+	marketQtyDecimals int
+}
+
+// RoundQuantity arredonda a quantidade para baixo no step do LOT_SIZE, ou do
+// MARKET_LOT_SIZE se market for true
+func (r SymbolRules) RoundQuantity(qty float64, market bool) float64 {
+	step, decimals := r.StepSize, r.qtyDecimals
+	if market && r.MarketStepSize > 0 {
+		step, decimals = r.MarketStepSize, r.marketQtyDecimals
+	}
+	if step <= 0 {
+		return qty
+	}
+	return roundTo(math.Floor(qty/step+1e-9)*step, decimals)
+}
+
+// RoundPrice arredonda o preço para o tick mais próximo
+func (r SymbolRules) RoundPrice(price float64) float64 {
+	if r.TickSize <= 0 {
+		return price
+	}
+	return roundTo(math.Round(price/r.TickSize)*r.TickSize, r.priceDecimals)
+}
+
+// Validate verifica uma ordem (já arredondada) contra os filtros do símbolo.
+// markPrice é usado no MIN_NOTIONAL e no PERCENT_PRICE; price pode ser zero
+// para ordens a mercado.
+func (r SymbolRules) Validate(qty, price, markPrice float64, market bool) error {
+	minQty, maxQty := r.MinQty, r.MaxQty
+	if market && r.MarketStepSize > 0 {
+		minQty, maxQty = r.MarketMinQty, r.MarketMaxQty
+	}
+	if qty < minQty {
+		return fmt.Errorf("%s: quantidade %v abaixo do mínimo %v", r.Symbol, qty, minQty)
+	}
+	if maxQty > 0 && qty > maxQty {
+		return fmt.Errorf("%s: quantidade %v acima do máximo %v", r.Symbol, qty, maxQty)
+	}
+	ref := price
+	if ref <= 0 {
+		ref = markPrice
+	}
+	if r.MinNotional > 0 && qty*ref < r.MinNotional {
+		return fmt.Errorf("%s: notional %.4f abaixo do mínimo %v", r.Symbol, qty*ref, r.MinNotional)
+	}
+	if price > 0 {
+		if price < r.MinPrice || (r.MaxPrice > 0 && price > r.MaxPrice) {
+			return fmt.Errorf("%s: preço %v fora da faixa [%v, %v]", r.Symbol, price, r.MinPrice, r.MaxPrice)
+		}
+		if markPrice > 0 && r.MultiplierUp > 0 &&
+			(price > markPrice*r.MultiplierUp || price < markPrice*r.MultiplierDown) {
+			return fmt.Errorf("%s: preço %v fora do PERCENT_PRICE em relação a %v", r.Symbol, price, markPrice)
+		}
+	}
+	return nil
+}
+
+// apply arredonda quantidade e preços de uma ordem pelas regras do símbolo
+func (r SymbolRules) apply(req OrderRequest) OrderRequest {
+	market := req.Price == 0
+	if req.Quantity > 0 {
+		req.Quantity = r.RoundQuantity(req.Quantity, market)
+	}
+	if req.Price > 0 {
+		req.Price = r.RoundPrice(req.Price)
+	}
+	if req.StopPrice > 0 {
+		req.StopPrice = r.RoundPrice(req.StopPrice)
+	}
+	if req.ActivationPrice > 0 {
+		req.ActivationPrice = r.RoundPrice(req.ActivationPrice)
+	}
+	return req
+}
+
+func roundTo(v float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(v*factor) / factor
+}
+
+// decimalsOf conta as casas decimais significativas de um filtro ("0.00100" => 3)
+func decimalsOf(s string) int {
+	_, frac, ok := strings.Cut(s, ".")
+	if !ok {
+		return 0
+	}
+	return len(strings.TrimRight(frac, "0"))
+}
+
+type rawFilter struct {
+	FilterType     string `json:"filterType"`
+	TickSize       string `json:"tickSize"`
+	MinPrice       string `json:"minPrice"`
+	MaxPrice       string `json:"maxPrice"`
+	StepSize       string `json:"stepSize"`
+	MinQty         string `json:"minQty"`
+	MaxQty         string `json:"maxQty"`
+	Notional       string `json:"notional"`
+	MultiplierUp   string `json:"multiplierUp"`
+	MultiplierDown string `json:"multiplierDown"`
+}
+
+type rawSymbol struct {
+	Symbol  string      `json:"symbol"`
+	Status  string      `json:"status"`
+	Filters []rawFilter `json:"filters"`
+}
+
+func (s rawSymbol) rules() SymbolRules {
+	r := SymbolRules{Symbol: s.Symbol, Status: s.Status}
+	for _, f := range s.Filters {
+		switch f.FilterType {
+		case "PRICE_FILTER":
+			r.TickSize = parseFloat(f.TickSize)
+			r.MinPrice = parseFloat(f.MinPrice)
+			r.MaxPrice = parseFloat(f.MaxPrice)
+			r.priceDecimals = decimalsOf(f.TickSize)
+		case "LOT_SIZE":
+			r.StepSize = parseFloat(f.StepSize)
+			r.MinQty = parseFloat(f.MinQty)
+			r.MaxQty = parseFloat(f.MaxQty)
+			r.qtyDecimals = decimalsOf(f.StepSize)
+		case "MARKET_LOT_SIZE":
+			r.MarketStepSize = parseFloat(f.StepSize)
+			r.MarketMinQty = parseFloat(f.MinQty)
+			r.MarketMaxQty = parseFloat(f.MaxQty)
+			r.marketQtyDecimals = decimalsOf(f.StepSize)
+		case "MIN_NOTIONAL":
+			r.MinNotional = parseFloat(f.Notional)
+		case "PERCENT_PRICE":
+			r.MultiplierUp = parseFloat(f.MultiplierUp)
+			r.MultiplierDown = parseFloat(f.MultiplierDown)
+		}
+	}
+	return r
+}
+
+// exchangeInfoCache guarda as regras por símbolo
+type exchangeInfoCache struct {
+	mu        sync.Mutex
+	rules     map[string]SymbolRules
+	fetchedAt time.Time
+}
+
+// LoadExchangeInfo baixa /fapi/v1/exchangeInfo e atualiza o cache de regras
+func (b *BinanceRestClient) LoadExchangeInfo() error {
+	body, err := b.publicRequest("/fapi/v1/exchangeInfo", url.Values{})
+	if err != nil {
+		return err
+	}
+	var info struct {
+		Symbols []rawSymbol `json:"symbols"`
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return fmt.Errorf("resposta de exchangeInfo inválida: %w", err)
+	}
+
+	rules := make(map[string]SymbolRules, len(info.Symbols))
+	for _, s := range info.Symbols {
+		rules[s.Symbol] = s.rules()
+	}
+	b.exchangeInfo.mu.Lock()
+	b.exchangeInfo.rules = rules
+	b.exchangeInfo.fetchedAt = time.Now()
+	b.exchangeInfo.mu.Unlock()
+	return nil
+}
+
+// SymbolRules retorna as regras de um símbolo, recarregando o exchangeInfo
+// quando o cache está vazio ou expirado
+func (b *BinanceRestClient) SymbolRules(symbol string) (SymbolRules, error) {
+	b.exchangeInfo.mu.Lock()
+	stale := b.exchangeInfo.rules == nil || time.Since(b.exchangeInfo.fetchedAt) > exchangeInfoTTL
+	b.exchangeInfo.mu.Unlock()
+
+	if stale {
+		if err := b.LoadExchangeInfo(); err != nil {
+			b.exchangeInfo.mu.Lock()
+			empty := b.exchangeInfo.rules == nil
+			b.exchangeInfo.mu.Unlock()
+			if empty {
+				return SymbolRules{}, err
+			}
+			log.Printf("⚠️ Falha ao atualizar exchangeInfo, usando cache: %v", err)
+		}
+	}
+
+	b.exchangeInfo.mu.Lock()
+	defer b.exchangeInfo.mu.Unlock()
+	r, ok := b.exchangeInfo.rules[symbol]
+	if !ok {
+		return SymbolRules{}, fmt.Errorf("símbolo %s não encontrado no exchangeInfo", symbol)
+	}
+	return r, nil
+}
@@ -0,0 +1,55 @@
+// internal/binance/exchangeinfo_test.go
+package binance
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const exchangeInfoJSON = `{"symbols":[{"symbol":"BTCUSDT","status":"TRADING","filters":[
+	{"filterType":"PRICE_FILTER","tickSize":"0.10","minPrice":"556.80","maxPrice":"4529764"},
+	{"filterType":"LOT_SIZE","stepSize":"0.001","minQty":"0.001","maxQty":"1000"},
+	{"filterType":"MARKET_LOT_SIZE","stepSize":"0.001","minQty":"0.001","maxQty":"120"},
+	{"filterType":"MIN_NOTIONAL","notional":"100"},
+	{"filterType":"PERCENT_PRICE","multiplierUp":"1.0500","multiplierDown":"0.9500"}]}]}`
+
+func TestSymbolRules(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(exchangeInfoJSON))
+	}))
+	defer srv.Close()
+
+	client := &BinanceRestClient{BaseURL: srv.URL}
+	rules, err := client.SymbolRules("BTCUSDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.SymbolRules("BTCUSDT"); err != nil || requests != 1 {
+		t.Errorf("exchangeInfo deveria vir do cache (requests = %d)", requests)
+	}
+	if _, err := client.SymbolRules("FOOUSDT"); err == nil {
+		t.Error("SymbolRules de símbolo inexistente deveria falhar")
+	}
+
+	if q := rules.RoundQuantity(0.0129, true); q != 0.012 {
+		t.Errorf("RoundQuantity = %v; want 0.012", q)
+	}
+	if p := rules.RoundPrice(50000.06); p != 50000.1 {
+		t.Errorf("RoundPrice = %v; want 50000.1", p)
+	}
+	if err := rules.Validate(0.001, 0, 50000, true); err == nil {
+		t.Error("Validate deveria barrar notional de 50 USDT")
+	}
+	if err := rules.Validate(121, 0, 50000, true); err == nil {
+		t.Error("Validate deveria barrar quantidade acima do MARKET_LOT_SIZE")
+	}
+	if err := rules.Validate(0.01, 56000, 50000, false); err == nil {
+		t.Error("Validate deveria barrar preço fora do PERCENT_PRICE")
+	}
+	if err := rules.Validate(0.01, 0, 50000, true); err != nil {
+		t.Errorf("Validate = %v; want nil", err)
+	}
+}
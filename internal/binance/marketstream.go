@@ -0,0 +1,401 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"binance-bot/internal/indicators"
+	"binance-bot/internal/types"
+)
+
+// Parâmetros de conexão dos websockets
+const (
+	wsReadTimeout    = 5 * time.Minute
+	wsPingInterval   = time.Minute
+	wsWriteTimeout   = 10 * time.Second
+	wsMinBackoff     = time.Second
+	wsMaxBackoff     = time.Minute
+	streamBufferSize = 256
+)
+
+// KlineEvent é uma atualização de kline recebida pelo websocket
+type KlineEvent struct {
+	Symbol   string
+	Interval string
+	Kline    types.Kline
+	Closed   bool
+}
+
+// MarkPriceEvent é uma atualização de mark price recebida pelo websocket
+type MarkPriceEvent struct {
+	Symbol          string
+	MarkPrice       float64
+	IndexPrice      float64
+	FundingRate     float64
+	NextFundingTime int64
+	EventTime       int64
+}
+
+type streamKey struct {
+	symbol   string
+	interval string
+}
+
+// MarketStream assina os streams combinados de kline e mark price, mantém uma
+// janela de klines em memória por símbolo/intervalo e reconecta sozinho,
+// completando pelo REST os candles perdidos durante a queda.
+type MarketStream struct {
+	client     *BinanceRestClient
+	windowSize int
+
+	mu         sync.RWMutex
+	subs       []streamKey
+	windows    map[streamKey][]types.Kline
+	markPrices map[string]MarkPriceEvent
+
+	closed chan KlineEvent
+	marks  chan MarkPriceEvent
+}
+
+// NewMarketStream cria o stream. windowSize é o número de klines mantidos por
+// símbolo/intervalo.
+func NewMarketStream(client *BinanceRestClient, windowSize int) *MarketStream {
+	return &MarketStream{
+		client:     client,
+		windowSize: windowSize,
+		windows:    make(map[streamKey][]types.Kline),
+		markPrices: make(map[string]MarkPriceEvent),
+		closed:     make(chan KlineEvent, streamBufferSize),
+		marks:      make(chan MarkPriceEvent, streamBufferSize),
+	}
+}
+
+// Subscribe registra symbol@kline_interval e symbol@markPrice. Assinaturas
+// repetidas são ignoradas. Deve ser chamado antes de Run.
+func (s *MarketStream) Subscribe(symbol, interval string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := streamKey{symbol: symbol, interval: interval}
+	for _, sub := range s.subs {
+		if sub == key {
+			return
+		}
+	}
+	s.subs = append(s.subs, key)
+}
+
+// ClosedKlines entrega um evento por candle fechado. Se a fila encher os
+// eventos excedentes são descartados.
+func (s *MarketStream) ClosedKlines() <-chan KlineEvent {
+	return s.closed
+}
+
+// MarkPrices entrega as atualizações de mark price. Se ninguém consumir,
+// atualizações antigas são descartadas.
+func (s *MarketStream) MarkPrices() <-chan MarkPriceEvent {
+	return s.marks
+}
+
+// Klines retorna uma cópia da janela de klines (o último pode estar aberto)
+func (s *MarketStream) Klines(symbol, interval string) []types.Kline {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	window := s.windows[streamKey{symbol, interval}]
+	out := make([]types.Kline, len(window))
+	copy(out, window)
+	return out
+}
+
+// MarkPrice retorna o último mark price recebido
+func (s *MarketStream) MarkPrice(symbol string) (float64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ev, ok := s.markPrices[symbol]
+	return ev.MarkPrice, ok
+}
+
+// Run conecta e mantém o stream até ctx ser cancelado, reconectando com
+// backoff exponencial
+func (s *MarketStream) Run(ctx context.Context) error {
+	backoff := wsMinBackoff
+	for {
+		started := time.Now()
+		err := s.session(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if time.Since(started) > wsMaxBackoff {
+			backoff = wsMinBackoff
+		}
+		log.Printf("🔌 Websocket de mercado caiu (%v), reconectando em %v", err, backoff)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, wsMaxBackoff)
+	}
+}
+
+// session executa uma conexão: conecta, assina, completa pelo REST e lê até cair
+func (s *MarketStream) session(ctx context.Context) error {
+	s.mu.RLock()
+	subs := append([]streamKey(nil), s.subs...)
+	s.mu.RUnlock()
+
+	var streams []string
+	seen := make(map[string]bool)
+	for _, sub := range subs {
+		sym := strings.ToLower(sub.symbol)
+		streams = append(streams, fmt.Sprintf("%s@kline_%s", sym, sub.interval))
+		if !seen[sym] {
+			streams = append(streams, sym+"@markPrice@1s")
+			seen[sym] = true
+		}
+	}
+
+	conn, err := dialStream(ctx, s.client.StreamURL+"/stream")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stop := keepAlive(ctx, conn)
+	defer stop()
+
+	subscribe := map[string]interface{}{"method": "SUBSCRIBE", "params": streams, "id": time.Now().UnixMilli()}
+	conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := conn.WriteJSON(subscribe); err != nil {
+		return fmt.Errorf("erro ao assinar streams: %w", err)
+	}
+	log.Printf("🔌 Websocket de mercado conectado (%d streams)", len(streams))
+
+	for _, sub := range subs {
+		s.backfill(sub)
+	}
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+		s.handle(msg)
+	}
+}
+
+// backfill busca os klines pelo REST e mescla na janela, emitindo o último
+// candle fechado se ele for mais novo que o que já havia sido visto
+func (s *MarketStream) backfill(sub streamKey) {
+	raw, err := s.client.GetKlines(sub.symbol, sub.interval, s.windowSize)
+	if err != nil {
+		log.Printf("⚠️ Erro ao completar klines de %s %s: %v", sub.symbol, sub.interval, err)
+		return
+	}
+	fetched := indicators.ConvertToKlines(raw)
+	now := time.Now().UnixMilli()
+
+	s.mu.Lock()
+	before := lastClosed(s.windows[sub], now)
+	window := mergeKlines(s.windows[sub], fetched, s.windowSize)
+	s.windows[sub] = window
+	after := lastClosed(window, now)
+	s.mu.Unlock()
+
+	if before != nil && after != nil && after.OpenTime > before.OpenTime {
+		s.emitClosed(KlineEvent{Symbol: sub.symbol, Interval: sub.interval, Kline: *after, Closed: true})
+	}
+}
+
+type wsEnvelope struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// O encoding/json casa chaves sem diferenciar maiúsculas quando não há campo
+// exato, então chaves como "e"/"E" e "v"/"V" precisam de campos próprios
+// para não sobrescreverem umas às outras.
+type wsKline struct {
+	EventType string `json:"e"`
+	EventTime int64  `json:"E"`
+	Symbol    string `json:"s"`
+	K         struct {
+		OpenTime      int64  `json:"t"`
+		CloseTime     int64  `json:"T"`
+		Symbol        string `json:"s"`
+		Interval      string `json:"i"`
+		FirstTradeID  int64  `json:"f"`
+		LastTradeID   int64  `json:"L"`
+		Open          string `json:"o"`
+		Close         string `json:"c"`
+		High          string `json:"h"`
+		Low           string `json:"l"`
+		Volume        string `json:"v"`
+		Trades        int64  `json:"n"`
+		Closed        bool   `json:"x"`
+		QuoteVolume   string `json:"q"`
+		TakerBuy      string `json:"V"`
+		TakerBuyQuote string `json:"Q"`
+		Ignore        string `json:"B"`
+	} `json:"k"`
+}
+
+type wsMarkPrice struct {
+	EventType       string `json:"e"`
+	EventTime       int64  `json:"E"`
+	Symbol          string `json:"s"`
+	MarkPrice       string `json:"p"`
+	SettlePrice     string `json:"P"`
+	IndexPrice      string `json:"i"`
+	FundingRate     string `json:"r"`
+	NextFundingTime int64  `json:"T"`
+}
+
+func (s *MarketStream) handle(msg []byte) {
+	var env wsEnvelope
+	if err := json.Unmarshal(msg, &env); err != nil || env.Stream == "" {
+		return // resposta do SUBSCRIBE ou mensagem desconhecida
+	}
+
+	switch {
+	case strings.Contains(env.Stream, "@kline_"):
+		var k wsKline
+		if err := json.Unmarshal(env.Data, &k); err != nil {
+			log.Printf("⚠️ Kline inválido em %s: %v", env.Stream, err)
+			return
+		}
+		ev := KlineEvent{
+			Symbol:   k.Symbol,
+			Interval: k.K.Interval,
+			Closed:   k.K.Closed,
+			Kline: types.Kline{
+				OpenTime:  k.K.OpenTime,
+				Open:      parseFloat(k.K.Open),
+				High:      parseFloat(k.K.High),
+				Low:       parseFloat(k.K.Low),
+				Close:     parseFloat(k.K.Close),
+				Volume:    parseFloat(k.K.Volume),
+				CloseTime: k.K.CloseTime,
+			},
+		}
+		key := streamKey{ev.Symbol, ev.Interval}
+		s.mu.Lock()
+		s.windows[key] = mergeKlines(s.windows[key], []types.Kline{ev.Kline}, s.windowSize)
+		s.mu.Unlock()
+		if ev.Closed {
+			s.emitClosed(ev)
+		}
+
+	case strings.Contains(env.Stream, "@markPrice"):
+		var m wsMarkPrice
+		if err := json.Unmarshal(env.Data, &m); err != nil {
+			log.Printf("⚠️ Mark price inválido em %s: %v", env.Stream, err)
+			return
+		}
+		ev := MarkPriceEvent{
+			Symbol:          m.Symbol,
+			MarkPrice:       parseFloat(m.MarkPrice),
+			IndexPrice:      parseFloat(m.IndexPrice),
+			FundingRate:     parseFloat(m.FundingRate),
+			NextFundingTime: m.NextFundingTime,
+			EventTime:       m.EventTime,
+		}
+		s.mu.Lock()
+		s.markPrices[ev.Symbol] = ev
+		s.mu.Unlock()
+		select {
+		case s.marks <- ev:
+		default:
+		}
+	}
+}
+
+// emitClosed publica um candle fechado sem travar a leitura do websocket
+func (s *MarketStream) emitClosed(ev KlineEvent) {
+	select {
+	case s.closed <- ev:
+	default:
+		log.Printf("⚠️ Fila de candles fechados cheia, descartando %s %s", ev.Symbol, ev.Interval)
+	}
+}
+
+// mergeKlines combina a janela com novos klines (o mais novo vence no mesmo
+// OpenTime), mantém a ordem e limita ao tamanho da janela
+func mergeKlines(window, incoming []types.Kline, size int) []types.Kline {
+	byOpen := make(map[int64]types.Kline, len(window)+len(incoming))
+	for _, k := range window {
+		byOpen[k.OpenTime] = k
+	}
+	for _, k := range incoming {
+		byOpen[k.OpenTime] = k
+	}
+	merged := make([]types.Kline, 0, len(byOpen))
+	for _, k := range byOpen {
+		merged = append(merged, k)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].OpenTime < merged[j].OpenTime })
+	if size > 0 && len(merged) > size {
+		merged = merged[len(merged)-size:]
+	}
+	return merged
+}
+
+// lastClosed retorna o último kline já fechado em now (ms)
+func lastClosed(window []types.Kline, now int64) *types.Kline {
+	for i := len(window) - 1; i >= 0; i-- {
+		if window[i].CloseTime < now {
+			k := window[i]
+			return &k
+		}
+	}
+	return nil
+}
+
+// dialStream abre a conexão websocket com timeout de leitura e resposta a pings
+func dialStream(ctx context.Context, url string) (*websocket.Conn, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao conectar em %s: %w", url, err)
+	}
+	conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+	conn.SetPingHandler(func(data string) error {
+		conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(wsWriteTimeout))
+	})
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+	})
+	return conn, nil
+}
+
+// keepAlive envia pings periódicos e fecha a conexão quando ctx é cancelado,
+// destravando o ReadMessage. Retorna a função que encerra a goroutine.
+func keepAlive(ctx context.Context, conn *websocket.Conn) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(wsPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+					conn.Close()
+					return
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,82 @@
+// internal/binance/marketstream_test.go
+package binance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"binance-bot/internal/types"
+)
+
+func TestMarketStream(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fapi/v1/klines":
+			w.Write([]byte(`[[60000,"1","2","0.5","1.5","10",119999],[120000,"1.5","2","1","1.8","5",179999]]`))
+		case "/stream":
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			var sub struct {
+				Method string   `json:"method"`
+				Params []string `json:"params"`
+			}
+			if err := conn.ReadJSON(&sub); err != nil || sub.Method != "SUBSCRIBE" || len(sub.Params) != 2 {
+				t.Errorf("SUBSCRIBE inesperado: %+v (%v)", sub, err)
+				return
+			}
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"result":null,"id":1}`))
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"stream":"btcusdt@markPrice@1s","data":{"e":"markPriceUpdate","E":1,"s":"BTCUSDT","p":"1.9","P":"1.95","i":"1.8","r":"0.0001","T":2}}`))
+			// Payload completo da Binance: "L" (número) e "l" (texto) precisam de campos próprios
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"stream":"btcusdt@kline_1m","data":{"e":"kline","E":179999,"s":"BTCUSDT","k":{"t":120000,"T":179999,"s":"BTCUSDT","i":"1m","f":5501112,"L":5501190,"o":"1.5","c":"1.9","h":"2.1","l":"1","v":"7","n":79,"x":true,"q":"12.4","V":"3","Q":"5.3","B":"0"}}}`))
+			conn.ReadMessage() // mantém aberto até o cliente fechar
+		}
+	}))
+	defer srv.Close()
+
+	client := &BinanceRestClient{BaseURL: srv.URL, StreamURL: "ws" + strings.TrimPrefix(srv.URL, "http")}
+	stream := NewMarketStream(client, 100)
+	stream.Subscribe("BTCUSDT", "1m")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go stream.Run(ctx)
+
+	select {
+	case ev := <-stream.ClosedKlines():
+		if ev.Symbol != "BTCUSDT" || ev.Kline.Close != 1.9 || !ev.Closed {
+			t.Errorf("KlineEvent = %+v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nenhum candle fechado recebido")
+	}
+
+	klines := stream.Klines("BTCUSDT", "1m")
+	want := []types.Kline{
+		{OpenTime: 60000, Open: 1, High: 2, Low: 0.5, Close: 1.5, Volume: 10, CloseTime: 119999},
+		{OpenTime: 120000, Open: 1.5, High: 2.1, Low: 1, Close: 1.9, Volume: 7, CloseTime: 179999},
+	}
+	if len(klines) != 2 || klines[0] != want[0] || klines[1] != want[1] {
+		t.Errorf("Klines = %+v; want %+v", klines, want)
+	}
+	if p, ok := stream.MarkPrice("BTCUSDT"); !ok || p != 1.9 {
+		t.Errorf("MarkPrice = %v, %v; want 1.9", p, ok)
+	}
+}
+
+func TestMergeKlines(t *testing.T) {
+	window := []types.Kline{{OpenTime: 1, Close: 1}, {OpenTime: 2, Close: 2}}
+	merged := mergeKlines(window, []types.Kline{{OpenTime: 3, Close: 3}, {OpenTime: 2, Close: 20}}, 2)
+	if len(merged) != 2 || merged[0].Close != 20 || merged[1].OpenTime != 3 {
+		t.Errorf("mergeKlines = %+v", merged)
+	}
+}
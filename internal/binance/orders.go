@@ -0,0 +1,341 @@
+package binance
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Tipos de ordem da API de futuros
+const (
+	OrderTypeMarket             = "MARKET"
+	OrderTypeLimit              = "LIMIT"
+	OrderTypeStop               = "STOP"
+	OrderTypeStopMarket         = "STOP_MARKET"
+	OrderTypeTakeProfit         = "TAKE_PROFIT"
+	OrderTypeTakeProfitMarket   = "TAKE_PROFIT_MARKET"
+	OrderTypeTrailingStopMarket = "TRAILING_STOP_MARKET"
+)
+
+// Time in force das ordens limitadas
+const (
+	TimeInForceGTC = "GTC"
+	TimeInForceIOC = "IOC"
+	TimeInForceFOK = "FOK"
+	TimeInForceGTX = "GTX" // post-only
+)
+
+// Status de ordem
+const (
+	OrderStatusNew             = "NEW"
+	OrderStatusPartiallyFilled = "PARTIALLY_FILLED"
+	OrderStatusFilled          = "FILLED"
+	OrderStatusCanceled        = "CANCELED"
+	OrderStatusRejected        = "REJECTED"
+	OrderStatusExpired         = "EXPIRED"
+)
+
+// OrderRequest descreve uma nova ordem. Campos zerados não são enviados.
+type OrderRequest struct {
+	Symbol          string
+	Side            string
+	Type            string
+	Quantity        float64
+	Price           float64
+	StopPrice       float64
+	TimeInForce     string
+	ReduceOnly      bool
+	ClosePosition   bool
+	ActivationPrice float64
+	CallbackRate    float64
+	WorkingType     string
+	ClientOrderID   string
+}
+
+// Order é uma ordem como retornada pela Binance
+type Order struct {
+	Symbol        string
+	OrderID       int64
+	ClientOrderID string
+	Side          string
+	Type          string
+	Status        string
+	TimeInForce   string
+	Price         float64
+	StopPrice     float64
+	OrigQty       float64
+	ExecutedQty   float64
+	AvgPrice      float64
+	ReduceOnly    bool
+	ClosePosition bool
+	UpdateTime    int64
+}
+
+// rawOrder espelha o JSON da Binance, que envia números como string
+type rawOrder struct {
+	Symbol        string `json:"symbol"`
+	OrderID       int64  `json:"orderId"`
+	ClientOrderID string `json:"clientOrderId"`
+	Side          string `json:"side"`
+	Type          string `json:"type"`
+	Status        string `json:"status"`
+	TimeInForce   string `json:"timeInForce"`
+	Price         string `json:"price"`
+	StopPrice     string `json:"stopPrice"`
+	OrigQty       string `json:"origQty"`
+	ExecutedQty   string `json:"executedQty"`
+	AvgPrice      string `json:"avgPrice"`
+	ReduceOnly    bool   `json:"reduceOnly"`
+	ClosePosition bool   `json:"closePosition"`
+	UpdateTime    int64  `json:"updateTime"`
+}
+
+func (r rawOrder) order() Order {
+	return Order{
+		Symbol:        r.Symbol,
+		OrderID:       r.OrderID,
+		ClientOrderID: r.ClientOrderID,
+		Side:          r.Side,
+		Type:          r.Type,
+		Status:        r.Status,
+		TimeInForce:   r.TimeInForce,
+		Price:         parseFloat(r.Price),
+		StopPrice:     parseFloat(r.StopPrice),
+		OrigQty:       parseFloat(r.OrigQty),
+		ExecutedQty:   parseFloat(r.ExecutedQty),
+		AvgPrice:      parseFloat(r.AvgPrice),
+		ReduceOnly:    r.ReduceOnly,
+		ClosePosition: r.ClosePosition,
+		UpdateTime:    r.UpdateTime,
+	}
+}
+
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func decodeOrder(body []byte) (*Order, error) {
+	var raw rawOrder
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("resposta de ordem inválida: %w", err)
+	}
+	o := raw.order()
+	return &o, nil
+}
+
+// NewOrder envia uma ordem genérica para /fapi/v1/order. Quantidade e preços
+// são arredondados pelas regras do símbolo (exchangeInfo).
+func (b *BinanceRestClient) NewOrder(req OrderRequest) (*Order, error) {
+	if rules, err := b.SymbolRules(req.Symbol); err != nil {
+		log.Printf("⚠️ Regras de %s indisponíveis, enviando ordem sem arredondar: %v", req.Symbol, err)
+	} else {
+		req = rules.apply(req)
+		if req.Quantity <= 0 && !req.ClosePosition {
+			return nil, fmt.Errorf("%s: quantidade abaixo do step %v", req.Symbol, rules.StepSize)
+		}
+	}
+
+	params := url.Values{}
+	params.Add("symbol", req.Symbol)
+	params.Add("side", req.Side)
+	params.Add("type", req.Type)
+	if req.Quantity > 0 {
+		params.Add("quantity", formatFloat(req.Quantity))
+	}
+	if req.Price > 0 {
+		params.Add("price", formatFloat(req.Price))
+	}
+	if req.StopPrice > 0 {
+		params.Add("stopPrice", formatFloat(req.StopPrice))
+	}
+	if req.TimeInForce != "" {
+		params.Add("timeInForce", req.TimeInForce)
+	}
+	if req.ReduceOnly {
+		params.Add("reduceOnly", "true")
+	}
+	if req.ClosePosition {
+		params.Add("closePosition", "true")
+	}
+	if req.ActivationPrice > 0 {
+		params.Add("activationPrice", formatFloat(req.ActivationPrice))
+	}
+	if req.CallbackRate > 0 {
+		params.Add("callbackRate", strconv.FormatFloat(req.CallbackRate, 'f', 1, 64))
+	}
+	if req.WorkingType != "" {
+		params.Add("workingType", req.WorkingType)
+	}
+	if req.ClientOrderID != "" {
+		params.Add("newClientOrderId", req.ClientOrderID)
+	}
+
+	body, err := b.signedRequest(http.MethodPost, "/fapi/v1/order", params)
+	if err != nil {
+		return nil, err
+	}
+	order, err := decodeOrder(body)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("📨 Ordem %s %s %s criada: %d (%s)", order.Type, order.Side, order.Symbol, order.OrderID, order.Status)
+	return order, nil
+}
+
+// PlaceLimitOrder cria uma ordem LIMIT com o time in force informado (GTC se vazio)
+func (b *BinanceRestClient) PlaceLimitOrder(symbol, side string, quantity, price float64, timeInForce string) (*Order, error) {
+	if timeInForce == "" {
+		timeInForce = TimeInForceGTC
+	}
+	return b.NewOrder(OrderRequest{
+		Symbol:      symbol,
+		Side:        side,
+		Type:        OrderTypeLimit,
+		Quantity:    quantity,
+		Price:       price,
+		TimeInForce: timeInForce,
+	})
+}
+
+// PlaceStopOrder cria um STOP (limitado): ao atingir stopPrice envia uma LIMIT em price
+func (b *BinanceRestClient) PlaceStopOrder(symbol, side string, quantity, price, stopPrice float64, reduceOnly bool) (*Order, error) {
+	return b.NewOrder(OrderRequest{
+		Symbol:      symbol,
+		Side:        side,
+		Type:        OrderTypeStop,
+		Quantity:    quantity,
+		Price:       price,
+		StopPrice:   stopPrice,
+		TimeInForce: TimeInForceGTC,
+		ReduceOnly:  reduceOnly,
+		WorkingType: "MARK_PRICE",
+	})
+}
+
+// PlaceTakeProfitOrder cria um TAKE_PROFIT (limitado)
+func (b *BinanceRestClient) PlaceTakeProfitOrder(symbol, side string, quantity, price, stopPrice float64, reduceOnly bool) (*Order, error) {
+	return b.NewOrder(OrderRequest{
+		Symbol:      symbol,
+		Side:        side,
+		Type:        OrderTypeTakeProfit,
+		Quantity:    quantity,
+		Price:       price,
+		StopPrice:   stopPrice,
+		TimeInForce: TimeInForceGTC,
+		ReduceOnly:  reduceOnly,
+		WorkingType: "MARK_PRICE",
+	})
+}
+
+// PlaceStopMarketOrder cria um STOP_MARKET. Com quantity zero usa
+// closePosition e fecha a posição inteira; senão é reduce-only.
+func (b *BinanceRestClient) PlaceStopMarketOrder(symbol, side string, quantity, stopPrice float64) (*Order, error) {
+	return b.NewOrder(marketTrigger(symbol, side, OrderTypeStopMarket, quantity, stopPrice))
+}
+
+// PlaceTakeProfitMarketOrder cria um TAKE_PROFIT_MARKET. Com quantity zero
+// usa closePosition e fecha a posição inteira; senão é reduce-only.
+func (b *BinanceRestClient) PlaceTakeProfitMarketOrder(symbol, side string, quantity, stopPrice float64) (*Order, error) {
+	return b.NewOrder(marketTrigger(symbol, side, OrderTypeTakeProfitMarket, quantity, stopPrice))
+}
+
+func marketTrigger(symbol, side, orderType string, quantity, stopPrice float64) OrderRequest {
+	return OrderRequest{
+		Symbol:        symbol,
+		Side:          side,
+		Type:          orderType,
+		Quantity:      quantity,
+		StopPrice:     stopPrice,
+		ReduceOnly:    quantity > 0,
+		ClosePosition: quantity == 0,
+		WorkingType:   "MARK_PRICE",
+	}
+}
+
+// PlaceTrailingStopMarketOrder cria um TRAILING_STOP_MARKET reduce-only que é
+// ativado em activationPrice e dispara após recuo de callbackRate% do extremo.
+func (b *BinanceRestClient) PlaceTrailingStopMarketOrder(symbol, side string, quantity, activationPrice, callbackRate float64) (*Order, error) {
+	return b.NewOrder(OrderRequest{
+		Symbol:          symbol,
+		Side:            side,
+		Type:            OrderTypeTrailingStopMarket,
+		Quantity:        quantity,
+		ActivationPrice: activationPrice,
+		CallbackRate:    callbackRate,
+		ReduceOnly:      true,
+		WorkingType:     "MARK_PRICE",
+	})
+}
+
+// CancelOrder cancela uma ordem aberta pelo orderId
+func (b *BinanceRestClient) CancelOrder(symbol string, orderID int64) (*Order, error) {
+	params := url.Values{}
+	params.Add("symbol", symbol)
+	params.Add("orderId", strconv.FormatInt(orderID, 10))
+	body, err := b.signedRequest(http.MethodDelete, "/fapi/v1/order", params)
+	if err != nil {
+		return nil, err
+	}
+	return decodeOrder(body)
+}
+
+// CancelOrderByClientID cancela uma ordem aberta pelo clientOrderId
+func (b *BinanceRestClient) CancelOrderByClientID(symbol, clientOrderID string) (*Order, error) {
+	params := url.Values{}
+	params.Add("symbol", symbol)
+	params.Add("origClientOrderId", clientOrderID)
+	body, err := b.signedRequest(http.MethodDelete, "/fapi/v1/order", params)
+	if err != nil {
+		return nil, err
+	}
+	return decodeOrder(body)
+}
+
+// CancelAllOrders cancela todas as ordens abertas de um símbolo
+func (b *BinanceRestClient) CancelAllOrders(symbol string) error {
+	params := url.Values{}
+	params.Add("symbol", symbol)
+	_, err := b.signedRequest(http.MethodDelete, "/fapi/v1/allOpenOrders", params)
+	return err
+}
+
+// GetOrder consulta uma ordem pelo orderId
+func (b *BinanceRestClient) GetOrder(symbol string, orderID int64) (*Order, error) {
+	params := url.Values{}
+	params.Add("symbol", symbol)
+	params.Add("orderId", strconv.FormatInt(orderID, 10))
+	body, err := b.signedRequest(http.MethodGet, "/fapi/v1/order", params)
+	if err != nil {
+		return nil, err
+	}
+	return decodeOrder(body)
+}
+
+// GetOpenOrders lista as ordens abertas de um símbolo, ou de todos se symbol for vazio
+func (b *BinanceRestClient) GetOpenOrders(symbol string) ([]Order, error) {
+	params := url.Values{}
+	if symbol != "" {
+		params.Add("symbol", symbol)
+	}
+	body, err := b.signedRequest(http.MethodGet, "/fapi/v1/openOrders", params)
+	if err != nil {
+		return nil, err
+	}
+	var raw []rawOrder
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("resposta de ordens abertas inválida: %w", err)
+	}
+	orders := make([]Order, len(raw))
+	for i, r := range raw {
+		orders[i] = r.order()
+	}
+	return orders, nil
+}
@@ -0,0 +1,111 @@
+// internal/binance/orders_test.go
+package binance
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPlaceLimitOrder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fapi/v1/exchangeInfo" {
+			w.Write([]byte(exchangeInfoJSON))
+			return
+		}
+		r.ParseForm()
+		if r.Form.Get("type") != "LIMIT" || r.Form.Get("timeInForce") != "IOC" || r.Form.Get("signature") == "" {
+			t.Errorf("parâmetros inesperados: %v", r.Form)
+		}
+		if r.Form.Get("quantity") != "0.01" || r.Form.Get("price") != "50000.1" {
+			t.Errorf("ordem não arredondada pelas regras: %v", r.Form)
+		}
+		w.Write([]byte(`{"symbol":"BTCUSDT","orderId":42,"clientOrderId":"abc","side":"BUY","type":"LIMIT",
+			"status":"PARTIALLY_FILLED","timeInForce":"IOC","price":"50000","origQty":"0.010",
+			"executedQty":"0.004","avgPrice":"49999.5","updateTime":1700000000000}`))
+	}))
+	defer srv.Close()
+
+	client := &BinanceRestClient{APIKey: "k", APISecret: "s", BaseURL: srv.URL}
+	order, err := client.PlaceLimitOrder("BTCUSDT", "BUY", 0.0109, 50000.08, TimeInForceIOC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.OrderID != 42 || order.Status != OrderStatusPartiallyFilled || order.ExecutedQty != 0.004 ||
+		order.AvgPrice != 49999.5 || order.UpdateTime != 1700000000000 {
+		t.Errorf("Order = %+v", order)
+	}
+}
+
+func TestGetOpenOrdersError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":-1121,"msg":"Invalid symbol."}`))
+	}))
+	defer srv.Close()
+
+	client := &BinanceRestClient{APIKey: "k", APISecret: "s", BaseURL: srv.URL}
+	if _, err := client.GetOpenOrders("FOOUSDT"); err == nil {
+		t.Error("GetOpenOrders deveria retornar erro da Binance")
+	}
+}
+
+func TestAPIErrorHelpers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fapi/v2/account":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":-1021,"msg":"Timestamp for this request is outside of the recvWindow."}`))
+		case "/fapi/v1/order":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":-2019,"msg":"Margin is insufficient."}`))
+		case "/fapi/v1/premiumIndex":
+			w.Write([]byte(`{"symbol":"BTCUSDT"}`))
+		case "/fapi/v1/exchangeInfo":
+			w.Write([]byte(`{"symbols":[]}`))
+		case "/fapi/v1/time":
+			w.Write([]byte(`{"serverTime":0}`))
+		default:
+			w.Header().Set("Retry-After", "5")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+	client := &BinanceRestClient{APIKey: "k", APISecret: "s", BaseURL: srv.URL}
+
+	if _, err := client.GetUSDTBalance(); !IsTimestampOutsideRecvWindow(err) {
+		t.Errorf("GetUSDTBalance err = %v; want -1021", err)
+	}
+	if _, err := client.PlaceMarketOrder("BTCUSDT", "BUY", 1, false); !IsInsufficientMargin(err) {
+		t.Errorf("PlaceMarketOrder err = %v; want margem insuficiente", err)
+	}
+	if _, err := client.GetMarkPrice("BTCUSDT"); err == nil {
+		t.Error("GetMarkPrice sem markPrice deveria retornar erro")
+	}
+	_, err := client.GetKlines("BTCUSDT", "1m", 10)
+	if apiErr, ok := err.(*APIError); !ok || apiErr.StatusCode != http.StatusTooManyRequests || !IsRateLimited(err) {
+		t.Errorf("GetKlines err = %v; want 429", err)
+	}
+}
+
+func TestCanceledContextLetsOrdersFinish(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"symbol":"BTCUSDT","orderId":7,"status":"CANCELED","type":"STOP_MARKET","side":"SELL"}`))
+	}))
+	defer srv.Close()
+
+	client := &BinanceRestClient{BaseURL: srv.URL}
+	ctx, cancel := context.WithCancel(context.Background())
+	client.SetContext(ctx)
+	cancel()
+
+	if _, err := client.GetOpenOrders("BTCUSDT"); !errors.Is(err, context.Canceled) {
+		t.Errorf("GET com contexto cancelado: err = %v; want context.Canceled", err)
+	}
+	order, err := client.CancelOrder("BTCUSDT", 7)
+	if err != nil || order.Status != OrderStatusCanceled {
+		t.Errorf("CancelOrder com contexto cancelado = %+v, %v; want CANCELED", order, err)
+	}
+}
@@ -0,0 +1,166 @@
+package binance
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Limites da API de futuros. O limiter só usa até safetyMargin de cada um
+// para deixar folga para outras conexões com a mesma chave/IP.
+const (
+	weightLimit1m = 2400
+	orderLimit1m  = 1200
+	orderLimit10s = 300
+	safetyMargin  = 0.9
+
+	// Espera padrão após 429/418 sem Retry-After
+	defaultRetryAfter = 30 * time.Second
+)
+
+// rateLimiter controla o peso de requisições e a contagem de ordens por
+// janela, atrasando requisições antes de estourar os limites da Binance. O
+// valor zero está pronto para uso.
+type rateLimiter struct {
+	mu sync.Mutex
+
+	weightWindow time.Time
+	usedWeight   int
+
+	orderWindow1m  time.Time
+	orders1m       int
+	orderWindow10s time.Time
+	orders10s      int
+
+	blockedUntil time.Time
+}
+
+// wait bloqueia até a requisição caber nos limites e a reserva, ou até ctx
+// ser cancelado
+func (l *rateLimiter) wait(ctx context.Context, weight int, isOrder bool) error {
+	for {
+		delay := l.reserve(time.Now(), weight, isOrder)
+		if delay <= 0 {
+			return nil
+		}
+		log.Printf("🐢 Limite de requisições próximo, aguardando %v", delay.Round(time.Millisecond))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// reserve contabiliza a requisição se ela couber nos limites em now, ou
+// retorna quanto tempo esperar
+func (l *rateLimiter) reserve(now time.Time, weight int, isOrder bool) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Before(l.blockedUntil) {
+		return l.blockedUntil.Sub(now)
+	}
+	l.roll(now)
+
+	if l.usedWeight+weight > int(weightLimit1m*safetyMargin) {
+		return l.weightWindow.Add(time.Minute).Sub(now)
+	}
+	if isOrder {
+		if l.orders10s+1 > int(orderLimit10s*safetyMargin) {
+			return l.orderWindow10s.Add(10 * time.Second).Sub(now)
+		}
+		if l.orders1m+1 > int(orderLimit1m*safetyMargin) {
+			return l.orderWindow1m.Add(time.Minute).Sub(now)
+		}
+		l.orders10s++
+		l.orders1m++
+	}
+	l.usedWeight += weight
+	return 0
+}
+
+// roll zera os contadores quando a janela correspondente vira
+func (l *rateLimiter) roll(now time.Time) {
+	if w := now.Truncate(time.Minute); !w.Equal(l.weightWindow) {
+		l.weightWindow = w
+		l.usedWeight = 0
+	}
+	if w := now.Truncate(time.Minute); !w.Equal(l.orderWindow1m) {
+		l.orderWindow1m = w
+		l.orders1m = 0
+	}
+	if w := now.Truncate(10 * time.Second); !w.Equal(l.orderWindow10s) {
+		l.orderWindow10s = w
+		l.orders10s = 0
+	}
+}
+
+// update sincroniza os contadores com os valores informados pela Binance nos
+// headers da resposta e aplica o Retry-After em 429/418
+func (l *rateLimiter) update(now time.Time, status int, h http.Header) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.roll(now)
+
+	if v, err := strconv.Atoi(h.Get("X-MBX-USED-WEIGHT-1M")); err == nil {
+		l.usedWeight = v
+	}
+	if v, err := strconv.Atoi(h.Get("X-MBX-ORDER-COUNT-1M")); err == nil {
+		l.orders1m = v
+	}
+	if v, err := strconv.Atoi(h.Get("X-MBX-ORDER-COUNT-10S")); err == nil {
+		l.orders10s = v
+	}
+
+	if status != http.StatusTooManyRequests && status != http.StatusTeapot {
+		return
+	}
+	retryAfter := defaultRetryAfter
+	if secs, err := strconv.Atoi(h.Get("Retry-After")); err == nil && secs > 0 {
+		retryAfter = time.Duration(secs) * time.Second
+	}
+	if until := now.Add(retryAfter); until.After(l.blockedUntil) {
+		l.blockedUntil = until
+	}
+	log.Printf("🚫 Binance retornou HTTP %d, pausando requisições por %v", status, retryAfter)
+}
+
+// endpointWeight retorna o peso de uma requisição conforme a documentação da API
+func endpointWeight(method, endpoint string, params url.Values) int {
+	switch endpoint {
+	case "/fapi/v1/klines":
+		limit, _ := strconv.Atoi(params.Get("limit"))
+		switch {
+		case limit == 0 || limit > 1000:
+			return 10
+		case limit >= 500:
+			return 5
+		case limit >= 100:
+			return 2
+		}
+		return 1
+	case "/fapi/v1/premiumIndex":
+		if params.Get("symbol") == "" {
+			return 10
+		}
+		return 1
+	case "/fapi/v1/openOrders":
+		if params.Get("symbol") == "" {
+			return 40
+		}
+		return 1
+	case "/fapi/v2/account", "/fapi/v2/positionRisk":
+		return 5
+	}
+	return 1
+}
+
+// isOrderRequest informa se a requisição conta no limite de ordens
+func isOrderRequest(method, endpoint string) bool {
+	return method == http.MethodPost && endpoint == "/fapi/v1/order"
+}
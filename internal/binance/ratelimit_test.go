@@ -0,0 +1,59 @@
+// internal/binance/ratelimit_test.go
+package binance
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterWeight(t *testing.T) {
+	var l rateLimiter
+	now := time.Date(2024, 1, 1, 12, 0, 30, 0, time.UTC)
+
+	h := http.Header{}
+	h.Set("X-MBX-USED-WEIGHT-1M", "2150")
+	l.update(now, http.StatusOK, h)
+
+	if d := l.reserve(now, 5, false); d != 0 {
+		t.Fatalf("reserve = %v; want 0", d)
+	}
+	// 2155 + 10 > 2160 (90% de 2400): espera a próxima janela de 1 minuto
+	if d := l.reserve(now, 10, false); d != 30*time.Second {
+		t.Errorf("reserve = %v; want 30s", d)
+	}
+	if d := l.reserve(now.Add(31*time.Second), 10, false); d != 0 {
+		t.Errorf("reserve na nova janela = %v; want 0", d)
+	}
+}
+
+func TestRateLimiterOrdersAndRetryAfter(t *testing.T) {
+	var l rateLimiter
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 270; i++ {
+		if d := l.reserve(now, 1, true); d != 0 {
+			t.Fatalf("ordem %d: reserve = %v; want 0", i, d)
+		}
+	}
+	if d := l.reserve(now, 1, true); d != 10*time.Second {
+		t.Errorf("reserve após 270 ordens em 10s = %v; want 10s", d)
+	}
+
+	h := http.Header{}
+	h.Set("Retry-After", "120")
+	l.update(now, http.StatusTeapot, h)
+	if d := l.reserve(now.Add(time.Minute), 1, false); d != time.Minute {
+		t.Errorf("reserve após 418 = %v; want 1m", d)
+	}
+}
+
+func TestEndpointWeight(t *testing.T) {
+	params := url.Values{"symbol": {"BTCUSDT"}, "limit": {"100"}}
+	if w := endpointWeight(http.MethodGet, "/fapi/v1/klines", params); w != 2 {
+		t.Errorf("klines limit=100 = %d; want 2", w)
+	}
+	if w := endpointWeight(http.MethodGet, "/fapi/v1/openOrders", url.Values{}); w != 40 {
+		t.Errorf("openOrders sem símbolo = %d; want 40", w)
+	}
+}
@@ -0,0 +1,87 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// RecvWindow padrão (ms) quando o client não define um
+const defaultRecvWindow = 5000
+
+// timeSync guarda a diferença entre o relógio da Binance e o local
+type timeSync struct {
+	mu       sync.RWMutex
+	offset   time.Duration
+	lastSync time.Time
+}
+
+// SyncTime consulta /fapi/v1/time e recalcula o offset usado nos timestamps
+// das requisições assinadas, compensando metade da latência de ida e volta
+func (b *BinanceRestClient) SyncTime() error {
+	start := time.Now()
+	body, err := b.publicRequest("/fapi/v1/time", url.Values{})
+	if err != nil {
+		return fmt.Errorf("erro ao sincronizar horário: %w", err)
+	}
+	end := time.Now()
+
+	var result struct {
+		ServerTime int64 `json:"serverTime"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("resposta de horário inválida: %w", err)
+	}
+	local := start.Add(end.Sub(start) / 2)
+	offset := time.UnixMilli(result.ServerTime).Sub(local)
+
+	b.clock.mu.Lock()
+	b.clock.offset = offset
+	b.clock.lastSync = end
+	b.clock.mu.Unlock()
+	if offset.Abs() > time.Second {
+		log.Printf("🕒 Relógio local difere da Binance em %v", offset.Round(time.Millisecond))
+	}
+	return nil
+}
+
+// StartTimeSync ressincroniza o horário a cada interval até ctx ser cancelado
+func (b *BinanceRestClient) StartTimeSync(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := b.SyncTime(); err != nil {
+					log.Printf("⚠️ %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// TimeOffset retorna o offset atual entre o horário da Binance e o local
+func (b *BinanceRestClient) TimeOffset() time.Duration {
+	b.clock.mu.RLock()
+	defer b.clock.mu.RUnlock()
+	return b.clock.offset
+}
+
+// timestamp retorna o horário da Binance estimado, em ms
+func (b *BinanceRestClient) timestamp() int64 {
+	return time.Now().Add(b.TimeOffset()).UnixMilli()
+}
+
+func (b *BinanceRestClient) recvWindow() int64 {
+	if b.RecvWindow > 0 {
+		return b.RecvWindow
+	}
+	return defaultRecvWindow
+}
@@ -0,0 +1,47 @@
+// internal/binance/timesync_test.go
+package binance
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignedRequestResyncsClock(t *testing.T) {
+	skew := 10 * time.Second
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serverNow := time.Now().Add(skew)
+		if r.URL.Path == "/fapi/v1/time" {
+			fmt.Fprintf(w, `{"serverTime":%d}`, serverNow.UnixMilli())
+			return
+		}
+		calls++
+		ts, _ := strconv.ParseInt(r.URL.Query().Get("timestamp"), 10, 64)
+		if r.URL.Query().Get("recvWindow") != "3000" {
+			t.Errorf("recvWindow = %s; want 3000", r.URL.Query().Get("recvWindow"))
+		}
+		if serverNow.UnixMilli()-ts > 3000 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":-1021,"msg":"Timestamp for this request is outside of the recvWindow."}`))
+			return
+		}
+		w.Write([]byte(`{"availableBalance":"123.45","totalMarginBalance":"150"}`))
+	}))
+	defer srv.Close()
+
+	client := &BinanceRestClient{APIKey: "k", APISecret: "s", BaseURL: srv.URL, RecvWindow: 3000}
+	balance, err := client.GetUSDTBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance != 123.45 || calls != 2 {
+		t.Errorf("balance = %v, chamadas = %d; want 123.45, 2", balance, calls)
+	}
+	if off := client.TimeOffset(); off < 9*time.Second || off > 11*time.Second {
+		t.Errorf("TimeOffset = %v; want ~10s", off)
+	}
+}
@@ -0,0 +1,474 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Intervalo de renovação do listenKey (expira após 60 minutos sem keepalive)
+const listenKeyKeepAlive = 30 * time.Minute
+
+// Código retornado quando o listenKey não existe mais
+const codeListenKeyNotFound = -1125
+
+// OrderUpdate é um evento ORDER_TRADE_UPDATE do user data stream
+type OrderUpdate struct {
+	Symbol          string
+	OrderID         int64
+	ClientOrderID   string
+	Side            string
+	Type            string
+	ExecutionType   string // NEW, TRADE, CANCELED, EXPIRED, AMENDMENT...
+	Status          string
+	PositionSide    string
+	Quantity        float64
+	Price           float64
+	AvgPrice        float64
+	StopPrice       float64
+	LastFilledQty   float64
+	LastFilledPrice float64
+	FilledQty       float64
+	Commission      float64
+	CommissionAsset string
+	RealizedPnL     float64
+	ReduceOnly      bool
+	ClosePosition   bool
+	TradeID         int64
+	TradeTime       int64
+	EventTime       int64
+}
+
+// BalanceUpdate é o saldo de um ativo em um ACCOUNT_UPDATE
+type BalanceUpdate struct {
+	Asset              string
+	WalletBalance      float64
+	CrossWalletBalance float64
+	BalanceChange      float64
+}
+
+// PositionUpdate é uma posição em um ACCOUNT_UPDATE
+type PositionUpdate struct {
+	Symbol         string
+	PositionSide   string
+	PositionAmt    float64
+	EntryPrice     float64
+	AccumulatedPnL float64
+	UnrealizedPnL  float64
+	MarginType     string
+}
+
+// AccountUpdate é um evento ACCOUNT_UPDATE (saldos e posições alterados)
+type AccountUpdate struct {
+	Reason    string
+	Balances  []BalanceUpdate
+	Positions []PositionUpdate
+	EventTime int64
+}
+
+// MarginCallPosition é uma posição em risco informada no MARGIN_CALL
+type MarginCallPosition struct {
+	Symbol            string
+	PositionSide      string
+	PositionAmt       float64
+	MarkPrice         float64
+	UnrealizedPnL     float64
+	MaintenanceMargin float64
+}
+
+// MarginCall é um evento MARGIN_CALL
+type MarginCall struct {
+	CrossWalletBalance float64
+	Positions          []MarginCallPosition
+	EventTime          int64
+}
+
+// CreateListenKey cria (ou estende, se já existir) o listenKey do user data stream
+func (b *BinanceRestClient) CreateListenKey() (string, error) {
+	body, err := b.do(http.MethodPost, "/fapi/v1/listenKey", url.Values{}, true)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		ListenKey string `json:"listenKey"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || resp.ListenKey == "" {
+		return "", fmt.Errorf("resposta de listenKey inválida: %s", body)
+	}
+	return resp.ListenKey, nil
+}
+
+// KeepAliveListenKey renova a validade do listenKey por mais 60 minutos
+func (b *BinanceRestClient) KeepAliveListenKey() error {
+	_, err := b.do(http.MethodPut, "/fapi/v1/listenKey", url.Values{}, true)
+	return err
+}
+
+// CloseListenKey encerra o user data stream
+func (b *BinanceRestClient) CloseListenKey() error {
+	_, err := b.do(http.MethodDelete, "/fapi/v1/listenKey", url.Values{}, true)
+	return err
+}
+
+// UserStream consome o user data stream (ordens, saldos/posições e margin
+// call), mantendo o listenKey vivo e reconectando sozinho
+type UserStream struct {
+	client *BinanceRestClient
+
+	orders      chan OrderUpdate
+	accounts    chan AccountUpdate
+	marginCalls chan MarginCall
+}
+
+// NewUserStream cria o consumidor do user data stream
+func NewUserStream(client *BinanceRestClient) *UserStream {
+	return &UserStream{
+		client:      client,
+		orders:      make(chan OrderUpdate, streamBufferSize),
+		accounts:    make(chan AccountUpdate, streamBufferSize),
+		marginCalls: make(chan MarginCall, streamBufferSize),
+	}
+}
+
+// Orders entrega os eventos ORDER_TRADE_UPDATE
+func (u *UserStream) Orders() <-chan OrderUpdate {
+	return u.orders
+}
+
+// Accounts entrega os eventos ACCOUNT_UPDATE
+func (u *UserStream) Accounts() <-chan AccountUpdate {
+	return u.accounts
+}
+
+// MarginCalls entrega os eventos MARGIN_CALL
+func (u *UserStream) MarginCalls() <-chan MarginCall {
+	return u.marginCalls
+}
+
+// Run conecta e mantém o stream até ctx ser cancelado, reconectando com
+// backoff exponencial. Ao sair, fecha o listenKey.
+func (u *UserStream) Run(ctx context.Context) error {
+	defer func() {
+		if err := u.client.CloseListenKey(); err != nil {
+			log.Printf("⚠️ Erro ao fechar listenKey: %v", err)
+		}
+	}()
+	backoff := wsMinBackoff
+	for {
+		started := time.Now()
+		err := u.session(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if time.Since(started) > wsMaxBackoff {
+			backoff = wsMinBackoff
+		}
+		log.Printf("🔌 User data stream caiu (%v), reconectando em %v", err, backoff)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, wsMaxBackoff)
+	}
+}
+
+// session cria o listenKey, conecta e lê até a conexão cair ou o listenKey expirar
+func (u *UserStream) session(ctx context.Context) error {
+	key, err := u.client.CreateListenKey()
+	if err != nil {
+		return fmt.Errorf("erro ao criar listenKey: %w", err)
+	}
+	conn, err := dialStream(ctx, u.client.StreamURL+"/ws/"+key)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stop := keepAlive(ctx, conn)
+	defer stop()
+	log.Println("🔌 User data stream conectado")
+
+	// Renova o listenKey periodicamente; se ele sumiu, derruba a conexão
+	// para que a próxima sessão crie outro
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(listenKeyKeepAlive)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := u.client.KeepAliveListenKey()
+				if err == nil {
+					continue
+				}
+				log.Printf("⚠️ Erro ao renovar listenKey: %v", err)
+				if apiErr, ok := asAPIError(err); ok && apiErr.Code == codeListenKeyNotFound {
+					conn.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+		if err := u.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+// O encoding/json casa chaves sem diferenciar maiúsculas quando não há campo
+// exato, por isso os pares "s"/"S", "x"/"X", "l"/"L", "n"/"N" e "t"/"T"
+// estão todos declarados.
+type wsOrderUpdate struct {
+	EventType       string `json:"e"`
+	EventTime       int64  `json:"E"`
+	TransactionTime int64  `json:"T"`
+	O               struct {
+		Symbol          string `json:"s"`
+		ClientOrderID   string `json:"c"`
+		Side            string `json:"S"`
+		Type            string `json:"o"`
+		TimeInForce     string `json:"f"`
+		Quantity        string `json:"q"`
+		Price           string `json:"p"`
+		AvgPrice        string `json:"ap"`
+		StopPrice       string `json:"sp"`
+		ExecutionType   string `json:"x"`
+		Status          string `json:"X"`
+		OrderID         int64  `json:"i"`
+		LastFilledQty   string `json:"l"`
+		FilledQty       string `json:"z"`
+		LastFilledPrice string `json:"L"`
+		CommissionAsset string `json:"N"`
+		Commission      string `json:"n"`
+		TradeTime       int64  `json:"T"`
+		TradeID         int64  `json:"t"`
+		ReduceOnly      bool   `json:"R"`
+		PositionSide    string `json:"ps"`
+		ClosePosition   bool   `json:"cp"`
+		ActivationPrice string `json:"AP"`
+		RealizedPnL     string `json:"rp"`
+	} `json:"o"`
+}
+
+type wsAccountUpdate struct {
+	EventType       string `json:"e"`
+	EventTime       int64  `json:"E"`
+	TransactionTime int64  `json:"T"`
+	A               struct {
+		Reason   string `json:"m"`
+		Balances []struct {
+			Asset              string `json:"a"`
+			WalletBalance      string `json:"wb"`
+			CrossWalletBalance string `json:"cw"`
+			BalanceChange      string `json:"bc"`
+		} `json:"B"`
+		Positions []struct {
+			Symbol         string `json:"s"`
+			PositionAmt    string `json:"pa"`
+			EntryPrice     string `json:"ep"`
+			AccumulatedPnL string `json:"cr"`
+			UnrealizedPnL  string `json:"up"`
+			MarginType     string `json:"mt"`
+			PositionSide   string `json:"ps"`
+		} `json:"P"`
+	} `json:"a"`
+}
+
+type wsMarginCall struct {
+	EventType          string `json:"e"`
+	EventTime          int64  `json:"E"`
+	CrossWalletBalance string `json:"cw"`
+	Positions          []struct {
+		Symbol            string `json:"s"`
+		PositionSide      string `json:"ps"`
+		PositionAmt       string `json:"pa"`
+		MarkPrice         string `json:"mp"`
+		UnrealizedPnL     string `json:"up"`
+		MaintenanceMargin string `json:"mm"`
+	} `json:"p"`
+}
+
+// handle decodifica um evento e o publica. Retorna erro quando o listenKey
+// expirou e a sessão precisa ser recriada.
+func (u *UserStream) handle(msg []byte) error {
+	var head struct {
+		EventType string `json:"e"`
+		EventTime int64  `json:"E"`
+	}
+	if err := json.Unmarshal(msg, &head); err != nil {
+		log.Printf("⚠️ Evento inválido no user data stream: %v", err)
+		return nil
+	}
+
+	switch head.EventType {
+	case "ORDER_TRADE_UPDATE":
+		var ev wsOrderUpdate
+		if err := json.Unmarshal(msg, &ev); err != nil {
+			log.Printf("⚠️ ORDER_TRADE_UPDATE inválido: %v", err)
+			return nil
+		}
+		o := ev.O
+		publish(u.orders, OrderUpdate{
+			Symbol:          o.Symbol,
+			OrderID:         o.OrderID,
+			ClientOrderID:   o.ClientOrderID,
+			Side:            o.Side,
+			Type:            o.Type,
+			ExecutionType:   o.ExecutionType,
+			Status:          o.Status,
+			PositionSide:    o.PositionSide,
+			Quantity:        parseFloat(o.Quantity),
+			Price:           parseFloat(o.Price),
+			AvgPrice:        parseFloat(o.AvgPrice),
+			StopPrice:       parseFloat(o.StopPrice),
+			LastFilledQty:   parseFloat(o.LastFilledQty),
+			LastFilledPrice: parseFloat(o.LastFilledPrice),
+			FilledQty:       parseFloat(o.FilledQty),
+			Commission:      parseFloat(o.Commission),
+			CommissionAsset: o.CommissionAsset,
+			RealizedPnL:     parseFloat(o.RealizedPnL),
+			ReduceOnly:      o.ReduceOnly,
+			ClosePosition:   o.ClosePosition,
+			TradeID:         o.TradeID,
+			TradeTime:       o.TradeTime,
+			EventTime:       ev.EventTime,
+		}, "ORDER_TRADE_UPDATE")
+
+	case "ACCOUNT_UPDATE":
+		var ev wsAccountUpdate
+		if err := json.Unmarshal(msg, &ev); err != nil {
+			log.Printf("⚠️ ACCOUNT_UPDATE inválido: %v", err)
+			return nil
+		}
+		update := AccountUpdate{Reason: ev.A.Reason, EventTime: ev.EventTime}
+		for _, b := range ev.A.Balances {
+			update.Balances = append(update.Balances, BalanceUpdate{
+				Asset:              b.Asset,
+				WalletBalance:      parseFloat(b.WalletBalance),
+				CrossWalletBalance: parseFloat(b.CrossWalletBalance),
+				BalanceChange:      parseFloat(b.BalanceChange),
+			})
+		}
+		for _, p := range ev.A.Positions {
+			update.Positions = append(update.Positions, PositionUpdate{
+				Symbol:         p.Symbol,
+				PositionSide:   p.PositionSide,
+				PositionAmt:    parseFloat(p.PositionAmt),
+				EntryPrice:     parseFloat(p.EntryPrice),
+				AccumulatedPnL: parseFloat(p.AccumulatedPnL),
+				UnrealizedPnL:  parseFloat(p.UnrealizedPnL),
+				MarginType:     p.MarginType,
+			})
+		}
+		publish(u.accounts, update, "ACCOUNT_UPDATE")
+
+	case "MARGIN_CALL":
+		var ev wsMarginCall
+		if err := json.Unmarshal(msg, &ev); err != nil {
+			log.Printf("⚠️ MARGIN_CALL inválido: %v", err)
+			return nil
+		}
+		call := MarginCall{CrossWalletBalance: parseFloat(ev.CrossWalletBalance), EventTime: ev.EventTime}
+		for _, p := range ev.Positions {
+			call.Positions = append(call.Positions, MarginCallPosition{
+				Symbol:            p.Symbol,
+				PositionSide:      p.PositionSide,
+				PositionAmt:       parseFloat(p.PositionAmt),
+				MarkPrice:         parseFloat(p.MarkPrice),
+				UnrealizedPnL:     parseFloat(p.UnrealizedPnL),
+				MaintenanceMargin: parseFloat(p.MaintenanceMargin),
+			})
+		}
+		publish(u.marginCalls, call, "MARGIN_CALL")
+
+	case "listenKeyExpired":
+		return fmt.Errorf("listenKey expirado")
+	}
+	return nil
+}
+
+// publish entrega o evento sem travar a leitura do websocket
+func publish[T any](ch chan T, ev T, name string) {
+	select {
+	case ch <- ev:
+	default:
+		log.Printf("⚠️ Fila de %s cheia, descartando evento", name)
+	}
+}
+
+// Fill resume as execuções de uma ordem que terminou
+type Fill struct {
+	Symbol          string
+	OrderID         int64
+	Side            string
+	Quantity        float64
+	AvgPrice        float64
+	RealizedPnL     float64
+	Commission      float64
+	CommissionAsset string
+	ReduceOnly      bool
+}
+
+// Closing informa se a ordem reduziu uma posição (gerou PnL realizado)
+func (f Fill) Closing() bool {
+	return f.ReduceOnly || f.RealizedPnL != 0
+}
+
+// NetPnL é o PnL realizado descontando a taxa quando ela foi cobrada em USDT
+func (f Fill) NetPnL() float64 {
+	if f.CommissionAsset == "USDT" {
+		return f.RealizedPnL - f.Commission
+	}
+	return f.RealizedPnL
+}
+
+// FillAggregator junta as execuções parciais (eventos TRADE) de cada ordem e
+// devolve o resumo quando a ordem termina. Não é seguro para uso concorrente.
+type FillAggregator struct {
+	pending map[int64]*Fill
+}
+
+// Add processa um ORDER_TRADE_UPDATE. Retorna o Fill e true quando a ordem
+// terminou com alguma execução.
+func (a *FillAggregator) Add(u OrderUpdate) (Fill, bool) {
+	if a.pending == nil {
+		a.pending = make(map[int64]*Fill)
+	}
+	f, ok := a.pending[u.OrderID]
+	if u.ExecutionType == "TRADE" {
+		if !ok {
+			f = &Fill{Symbol: u.Symbol, OrderID: u.OrderID, Side: u.Side}
+			a.pending[u.OrderID] = f
+			ok = true
+		}
+		f.AvgPrice = (f.AvgPrice*f.Quantity + u.LastFilledPrice*u.LastFilledQty) / (f.Quantity + u.LastFilledQty)
+		f.Quantity += u.LastFilledQty
+		f.RealizedPnL += u.RealizedPnL
+		f.Commission += u.Commission
+		f.CommissionAsset = u.CommissionAsset
+		f.ReduceOnly = f.ReduceOnly || u.ReduceOnly || u.ClosePosition
+	}
+	switch u.Status {
+	case OrderStatusFilled, OrderStatusCanceled, OrderStatusExpired:
+		if !ok {
+			return Fill{}, false
+		}
+		delete(a.pending, u.OrderID)
+		return *f, true
+	}
+	return Fill{}, false
+}
@@ -0,0 +1,103 @@
+// internal/binance/userstream_test.go
+package binance
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListenKey(t *testing.T) {
+	var methods []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fapi/v1/listenKey" || r.Header.Get("X-MBX-APIKEY") != "key" {
+			t.Errorf("requisição inesperada: %s %s", r.Method, r.URL.Path)
+		}
+		if r.URL.Query().Get("signature") != "" {
+			t.Errorf("listenKey não deve ser assinado")
+		}
+		methods = append(methods, r.Method)
+		w.Write([]byte(`{"listenKey":"abc123"}`))
+	}))
+	defer srv.Close()
+
+	client := &BinanceRestClient{APIKey: "key", BaseURL: srv.URL}
+	key, err := client.CreateListenKey()
+	if err != nil || key != "abc123" {
+		t.Fatalf("CreateListenKey = %q, %v", key, err)
+	}
+	if err := client.KeepAliveListenKey(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CloseListenKey(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{http.MethodPost, http.MethodPut, http.MethodDelete}
+	for i := range want {
+		if i >= len(methods) || methods[i] != want[i] {
+			t.Fatalf("métodos = %v; want %v", methods, want)
+		}
+	}
+}
+
+func TestUserStreamHandle(t *testing.T) {
+	u := NewUserStream(&BinanceRestClient{})
+
+	u.handle([]byte(`{"e":"ORDER_TRADE_UPDATE","E":10,"T":9,"o":{"s":"BTCUSDT","c":"x","S":"SELL","o":"MARKET","f":"GTC","q":"0.002","p":"0","ap":"50000","sp":"0","x":"TRADE","X":"FILLED","i":7,"l":"0.002","z":"0.002","L":"50000","N":"USDT","n":"0.04","T":9,"t":99,"R":true,"ps":"BOTH","cp":false,"AP":"0","rp":"1.5"}}`))
+	o := <-u.Orders()
+	if o.Symbol != "BTCUSDT" || o.Side != "SELL" || o.Type != "MARKET" || o.ExecutionType != "TRADE" ||
+		o.Status != OrderStatusFilled || o.LastFilledQty != 0.002 || o.LastFilledPrice != 50000 ||
+		o.Commission != 0.04 || o.CommissionAsset != "USDT" || o.TradeID != 99 || o.TradeTime != 9 ||
+		!o.ReduceOnly || o.RealizedPnL != 1.5 || o.AvgPrice != 50000 {
+		t.Errorf("OrderUpdate = %+v", o)
+	}
+
+	u.handle([]byte(`{"e":"ACCOUNT_UPDATE","E":11,"T":10,"a":{"m":"ORDER","B":[{"a":"USDT","wb":"100.5","cw":"100.5","bc":"0"}],"P":[{"s":"BTCUSDT","pa":"0","ep":"0","cr":"1.5","up":"0","mt":"cross","iw":"0","ps":"BOTH"}]}}`))
+	a := <-u.Accounts()
+	if a.Reason != "ORDER" || len(a.Balances) != 1 || a.Balances[0].WalletBalance != 100.5 ||
+		len(a.Positions) != 1 || a.Positions[0].AccumulatedPnL != 1.5 {
+		t.Errorf("AccountUpdate = %+v", a)
+	}
+
+	u.handle([]byte(`{"e":"MARGIN_CALL","E":12,"cw":"3.16","p":[{"s":"ETHUSDT","ps":"LONG","pa":"1.3","mt":"CROSSED","iw":"0","mp":"187.17","up":"-1.16","mm":"15.79"}]}`))
+	m := <-u.MarginCalls()
+	if m.CrossWalletBalance != 3.16 || len(m.Positions) != 1 || m.Positions[0].MaintenanceMargin != 15.79 {
+		t.Errorf("MarginCall = %+v", m)
+	}
+
+	if err := u.handle([]byte(`{"e":"listenKeyExpired","E":13,"listenKey":"abc"}`)); err == nil {
+		t.Error("listenKeyExpired deveria encerrar a sessão")
+	}
+}
+
+func TestFillAggregator(t *testing.T) {
+	var agg FillAggregator
+	updates := []OrderUpdate{
+		{OrderID: 1, Symbol: "BTCUSDT", Side: "SELL", ExecutionType: "NEW", Status: OrderStatusNew},
+		{OrderID: 1, Symbol: "BTCUSDT", Side: "SELL", ExecutionType: "TRADE", Status: OrderStatusPartiallyFilled,
+			LastFilledQty: 1, LastFilledPrice: 100, RealizedPnL: 2, Commission: 0.1, CommissionAsset: "USDT", ReduceOnly: true},
+		{OrderID: 1, Symbol: "BTCUSDT", Side: "SELL", ExecutionType: "TRADE", Status: OrderStatusFilled,
+			LastFilledQty: 3, LastFilledPrice: 104, RealizedPnL: 6, Commission: 0.3, CommissionAsset: "USDT", ReduceOnly: true},
+	}
+	for i, u := range updates[:2] {
+		if _, done := agg.Add(u); done {
+			t.Fatalf("update %d não deveria concluir a ordem", i)
+		}
+	}
+	fill, done := agg.Add(updates[2])
+	if !done {
+		t.Fatal("ordem deveria estar concluída")
+	}
+	if fill.Quantity != 4 || fill.AvgPrice != 103 || fill.RealizedPnL != 8 || !fill.Closing() {
+		t.Errorf("Fill = %+v", fill)
+	}
+	if net := fill.NetPnL(); net < 7.6-1e-9 || net > 7.6+1e-9 {
+		t.Errorf("NetPnL = %v; want 7.6", net)
+	}
+
+	// Cancelada sem execução não gera Fill
+	agg.Add(OrderUpdate{OrderID: 2, ExecutionType: "NEW", Status: OrderStatusNew})
+	if _, done := agg.Add(OrderUpdate{OrderID: 2, ExecutionType: "CANCELED", Status: OrderStatusCanceled}); done {
+		t.Error("ordem cancelada sem execução não deveria gerar Fill")
+	}
+}
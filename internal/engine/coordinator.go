@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Coordinator guarda o estado de conta compartilhado pelos workers de cada
+// símbolo: saldo disponível, margem reservada para entradas em andamento,
+// pausa global da API e o limite de chamadas REST simultâneas.
+type Coordinator struct {
+	mu           sync.Mutex
+	balance      float64
+	reservations map[string]*reservation
+	pausedUntil  time.Time
+
+	calls chan struct{}
+}
+
+type reservation struct {
+	margin      float64
+	committedAt time.Time // zero enquanto a ordem não foi enviada
+}
+
+// NewCoordinator cria o coordenador permitindo até maxCalls blocos de
+// chamadas REST ao mesmo tempo
+func NewCoordinator(maxCalls int) *Coordinator {
+	if maxCalls < 1 {
+		maxCalls = 1
+	}
+	return &Coordinator{
+		reservations: make(map[string]*reservation),
+		calls:        make(chan struct{}, maxCalls),
+	}
+}
+
+// Call executa fn ocupando uma das vagas de chamadas REST
+func (c *Coordinator) Call(fn func()) {
+	c.calls <- struct{}{}
+	defer func() { <-c.calls }()
+	fn()
+}
+
+// SetBalance atualiza o saldo disponível lido da corretora. fetchedAt é o
+// momento em que a consulta começou: reservas de ordens enviadas antes disso
+// já estão refletidas no saldo e são liberadas.
+func (c *Coordinator) SetBalance(balance float64, fetchedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.balance = balance
+	for symbol, r := range c.reservations {
+		if !r.committedAt.IsZero() && r.committedAt.Before(fetchedAt) {
+			delete(c.reservations, symbol)
+		}
+	}
+}
+
+// Available retorna o saldo disponível descontando as reservas
+func (c *Coordinator) Available() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.available()
+}
+
+func (c *Coordinator) available() float64 {
+	avail := c.balance
+	for _, r := range c.reservations {
+		avail -= r.margin
+	}
+	return avail
+}
+
+// Reserve executa plan com o saldo disponível e reserva a margem retornada.
+// Só um plan roda por vez, então dois workers nunca dimensionam entradas a
+// partir do mesmo saldo. O worker deve chamar Commit após enviar a ordem ou
+// Release se ela não for enviada.
+func (c *Coordinator) Reserve(symbol string, plan func(available float64) (margin float64, err error)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.reservations[symbol]; ok {
+		return fmt.Errorf("%s: já existe uma entrada em andamento", symbol)
+	}
+	available := c.available()
+	margin, err := plan(available)
+	if err != nil {
+		return err
+	}
+	if margin > available {
+		return fmt.Errorf("%s: margem %.2f acima do saldo disponível %.2f", symbol, margin, available)
+	}
+	c.reservations[symbol] = &reservation{margin: margin}
+	return nil
+}
+
+// Commit marca a ordem da reserva como enviada; a reserva vale até o próximo
+// SetBalance consultado depois deste momento
+func (c *Coordinator) Commit(symbol string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if r, ok := c.reservations[symbol]; ok {
+		r.committedAt = time.Now()
+	}
+}
+
+// Release descarta a reserva de uma entrada que não foi enviada
+func (c *Coordinator) Release(symbol string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.reservations, symbol)
+}
+
+// Pause suspende as chamadas à API de todos os workers por d
+func (c *Coordinator) Pause(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if until := time.Now().Add(d); until.After(c.pausedUntil) {
+		c.pausedUntil = until
+	}
+}
+
+// Paused informa se a API está em pausa
+func (c *Coordinator) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Now().Before(c.pausedUntil)
+}
@@ -0,0 +1,73 @@
+// internal/engine/coordinator_test.go
+package engine
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReserveSerializesBalance(t *testing.T) {
+	c := NewCoordinator(2)
+	c.SetBalance(100, time.Now())
+
+	// Dez workers tentam reservar 30 cada: só três cabem em 100
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	ok := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := c.Reserve(string(rune('A'+i)), func(available float64) (float64, error) {
+				return 30, nil
+			})
+			if err == nil {
+				mu.Lock()
+				ok++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if ok != 3 {
+		t.Errorf("reservas aceitas = %d; want 3", ok)
+	}
+	if got := c.Available(); got != 10 {
+		t.Errorf("Available = %v; want 10", got)
+	}
+}
+
+func TestReservationLifecycle(t *testing.T) {
+	c := NewCoordinator(1)
+	c.SetBalance(100, time.Now())
+	plan := func(available float64) (float64, error) { return available / 2, nil }
+
+	if err := c.Reserve("BTCUSDT", plan); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Reserve("BTCUSDT", plan); err == nil {
+		t.Error("segunda entrada no mesmo símbolo deveria ser recusada")
+	}
+
+	// Saldo consultado antes do Commit não libera a reserva
+	before := time.Now()
+	c.Commit("BTCUSDT")
+	c.SetBalance(100, before.Add(-time.Millisecond))
+	if got := c.Available(); got != 50 {
+		t.Errorf("Available = %v; want 50", got)
+	}
+	// Saldo consultado depois do Commit já desconta a margem
+	c.SetBalance(50, time.Now().Add(time.Millisecond))
+	if got := c.Available(); got != 50 {
+		t.Errorf("Available = %v; want 50", got)
+	}
+
+	if err := c.Reserve("ETHUSDT", plan); err != nil {
+		t.Fatal(err)
+	}
+	c.Release("ETHUSDT")
+	if got := c.Available(); got != 50 {
+		t.Errorf("Available após Release = %v; want 50", got)
+	}
+}
@@ -0,0 +1,177 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"binance-bot/config"
+	"binance-bot/internal/binance"
+	"binance-bot/internal/indicators"
+	"binance-bot/internal/types"
+)
+
+// Bar é um candle fechado pronto para avaliação. Klines termina no candle
+// fechado e não inclui o candle em formação. Higher traz os klines dos
+// intervalos de confirmação do símbolo, agregados a partir de Klines e
+// fechados até o mesmo instante.
+type Bar struct {
+	Symbol   string
+	Interval string
+	Klines   []types.Kline
+	Higher   map[string][]types.Kline
+}
+
+// Last retorna o candle que acabou de fechar
+func (b Bar) Last() types.Kline {
+	return b.Klines[len(b.Klines)-1]
+}
+
+// Engine transforma os klines do websocket em no máximo um Bar por candle
+// fechado de cada símbolo, no intervalo configurado para ele
+type Engine struct {
+	cfg    config.EngineConfig
+	stream *binance.MarketStream
+	klines func(symbol, interval string) []types.Kline
+
+	timeframes map[string][]string // intervalos de confirmação por símbolo
+	last       map[string]int64    // OpenTime do último candle avaliado por símbolo
+	bars       chan Bar
+}
+
+// New cria o engine sobre o stream de mercado
+func New(stream *binance.MarketStream, cfg config.EngineConfig) *Engine {
+	return &Engine{
+		cfg:        cfg,
+		stream:     stream,
+		klines:     stream.Klines,
+		timeframes: make(map[string][]string),
+		last:       make(map[string]int64),
+		bars:       make(chan Bar, 64),
+	}
+}
+
+// Subscribe assina no stream o intervalo configurado de cada símbolo. Os
+// intervalos extras retornados por timeframes (pode ser nil) não são
+// assinados: os Bars os montam agregando os klines do intervalo base, do qual
+// precisam ser múltiplos. Deve ser chamado antes de MarketStream.Run.
+func (e *Engine) Subscribe(symbols []string, timeframes func(symbol string) []string) error {
+	for _, symbol := range symbols {
+		base := e.cfg.IntervalFor(symbol)
+		if timeframes != nil {
+			for _, interval := range timeframes(symbol) {
+				if _, err := Window(base, []string{interval}, 1); err != nil {
+					return fmt.Errorf("%s: %w", symbol, err)
+				}
+				e.timeframes[symbol] = append(e.timeframes[symbol], interval)
+			}
+		}
+		e.stream.Subscribe(symbol, base)
+	}
+	return nil
+}
+
+// MaxWindow limita a janela de klines do intervalo base. Cada kline ocupa
+// 56 bytes e a janela é copiada e reagregada a cada candle fechado; no limite
+// são ~1,1 MB por símbolo e 14 páginas de backfill pelo REST. Uma
+// confirmação em 4h com média de 50 sobre candles de 1m usa 12480 klines.
+const MaxWindow = 20000
+
+// Window retorna quantos klines do intervalo base são necessários para
+// agregar lookback candles fechados de cada intervalo de timeframes. Um
+// candle maior a mais cobre o grupo inicial incompleto, que o resample
+// descarta, e o que ainda está em formação. Acima de MaxWindow retorna erro.
+func Window(base string, timeframes []string, lookback int) (int, error) {
+	baseDur, err := indicators.IntervalDuration(base)
+	if err != nil {
+		return 0, err
+	}
+	window := 0
+	for _, interval := range timeframes {
+		dur, err := indicators.IntervalDuration(interval)
+		if err != nil {
+			return 0, err
+		}
+		if dur < baseDur || dur%baseDur != 0 {
+			return 0, fmt.Errorf("intervalo de confirmação %s não é múltiplo de %s", interval, base)
+		}
+		need := (lookback + 2) * int(dur/baseDur)
+		if need > MaxWindow {
+			return 0, fmt.Errorf("confirmação em %s exige %d klines de %s, acima do limite de %d: use um intervalo base maior ou uma média menor", interval, need, base, MaxWindow)
+		}
+		window = max(window, need)
+	}
+	return window, nil
+}
+
+// Bars entrega um Bar por candle fechado
+func (e *Engine) Bars() <-chan Bar {
+	return e.bars
+}
+
+// Run consome os candles fechados do stream até ctx ser cancelado
+func (e *Engine) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev := <-e.stream.ClosedKlines():
+			bar, ok := e.accept(ev, time.Now())
+			if !ok {
+				continue
+			}
+			select {
+			case e.bars <- bar:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// accept decide se o evento é um candle fechado ainda não avaliado e monta o
+// Bar correspondente
+func (e *Engine) accept(ev binance.KlineEvent, now time.Time) (Bar, bool) {
+	if ev.Interval != e.cfg.IntervalFor(ev.Symbol) {
+		return Bar{}, false
+	}
+	if !ev.Closed && ev.Kline.CloseTime >= now.UnixMilli() {
+		return Bar{}, false
+	}
+	if ev.Kline.OpenTime <= e.last[ev.Symbol] {
+		return Bar{}, false // candle já avaliado (reconexão ou backfill)
+	}
+
+	var klines []types.Kline
+	for _, k := range e.klines(ev.Symbol, ev.Interval) {
+		if k.OpenTime < ev.Kline.OpenTime {
+			klines = append(klines, k)
+		}
+	}
+	klines = append(klines, ev.Kline)
+
+	if prev := e.last[ev.Symbol]; prev > 0 && len(klines) > 1 && klines[len(klines)-2].OpenTime > prev {
+		log.Printf("⚠️ %s %s: candles pulados entre %d e %d", ev.Symbol, ev.Interval, prev, ev.Kline.OpenTime)
+	}
+	e.last[ev.Symbol] = ev.Kline.OpenTime
+	return Bar{Symbol: ev.Symbol, Interval: ev.Interval, Klines: klines, Higher: e.higher(ev.Symbol, ev.Interval, klines)}, true
+}
+
+// higher agrega os klines do Bar nos intervalos de confirmação; o candle
+// maior ainda em formação fica de fora
+func (e *Engine) higher(symbol, base string, klines []types.Kline) map[string][]types.Kline {
+	intervals := e.timeframes[symbol]
+	if len(intervals) == 0 {
+		return nil
+	}
+	out := make(map[string][]types.Kline, len(intervals))
+	for _, interval := range intervals {
+		resampled, err := indicators.ResampleClosed(klines, base, interval)
+		if err != nil {
+			log.Printf("⚠️ %s: erro ao agregar %s em %s: %v", symbol, base, interval, err)
+		}
+		out[interval] = resampled
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+// internal/engine/engine_test.go
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"binance-bot/config"
+	"binance-bot/internal/binance"
+	"binance-bot/internal/types"
+)
+
+func newTestEngine(window []types.Kline) *Engine {
+	return &Engine{
+		cfg:    config.EngineConfig{Interval: "1m", PerSymbol: map[string]string{"ETHUSDT": "5m"}},
+		klines: func(symbol, interval string) []types.Kline { return window },
+		last:   make(map[string]int64),
+		bars:   make(chan Bar, 1),
+	}
+}
+
+func TestAcceptOncePerBar(t *testing.T) {
+	window := []types.Kline{
+		{OpenTime: 0, CloseTime: 59999, Close: 1},
+		{OpenTime: 60000, CloseTime: 119999, Close: 2},
+		{OpenTime: 120000, CloseTime: 179999, Close: 3}, // em formação
+	}
+	e := newTestEngine(window)
+	now := time.UnixMilli(130000)
+	closed := binance.KlineEvent{Symbol: "BTCUSDT", Interval: "1m", Closed: true,
+		Kline: types.Kline{OpenTime: 60000, CloseTime: 119999, Close: 2.5}}
+
+	bar, ok := e.accept(closed, now)
+	if !ok {
+		t.Fatal("candle fechado deveria gerar Bar")
+	}
+	if len(bar.Klines) != 2 || bar.Last().Close != 2.5 {
+		t.Errorf("Bar.Klines = %+v; want 2 candles terminando no fechado", bar.Klines)
+	}
+	if _, ok := e.accept(closed, now); ok {
+		t.Error("o mesmo candle não pode gerar dois Bars")
+	}
+}
+
+func TestAcceptFilters(t *testing.T) {
+	e := newTestEngine(nil)
+	now := time.UnixMilli(100000)
+
+	open := binance.KlineEvent{Symbol: "BTCUSDT", Interval: "1m", Kline: types.Kline{OpenTime: 60000, CloseTime: 119999}}
+	if _, ok := e.accept(open, now); ok {
+		t.Error("candle em formação não deveria gerar Bar")
+	}
+	// Sem o flag x, mas já passou do CloseTime
+	if _, ok := e.accept(open, time.UnixMilli(120000)); !ok {
+		t.Error("candle com CloseTime no passado deveria gerar Bar")
+	}
+
+	other := binance.KlineEvent{Symbol: "ETHUSDT", Interval: "1m", Closed: true, Kline: types.Kline{OpenTime: 60000, CloseTime: 119999}}
+	if _, ok := e.accept(other, now); ok {
+		t.Error("intervalo diferente do configurado para o símbolo não deveria gerar Bar")
+	}
+	other.Interval = "5m"
+	if _, ok := e.accept(other, now); !ok {
+		t.Error("intervalo configurado por símbolo deveria gerar Bar")
+	}
+}
+
+func TestAcceptHigherTimeframes(t *testing.T) {
+	// 12 candles de 1m: os de 5m fecham nos minutos 4 e 9, o terceiro está
+	// em formação
+	var base []types.Kline
+	for i := int64(0); i < 12; i++ {
+		base = append(base, types.Kline{OpenTime: i * 60000, CloseTime: (i+1)*60000 - 1, Open: float64(i), High: float64(i), Low: float64(i), Close: float64(i), Volume: 1})
+	}
+	e := newTestEngine(base)
+	e.timeframes = map[string][]string{"BTCUSDT": {"5m"}}
+
+	ev := binance.KlineEvent{Symbol: "BTCUSDT", Interval: "1m", Closed: true, Kline: base[11]}
+	bar, ok := e.accept(ev, time.UnixMilli(720000))
+	if !ok {
+		t.Fatal("candle fechado deveria gerar Bar")
+	}
+	want := []types.Kline{
+		{OpenTime: 0, Open: 0, High: 4, Low: 0, Close: 4, Volume: 5, CloseTime: 299999},
+		{OpenTime: 300000, Open: 5, High: 9, Low: 5, Close: 9, Volume: 5, CloseTime: 599999},
+	}
+	if got := bar.Higher["5m"]; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Higher[5m] = %+v; want %+v", got, want)
+	}
+}
+
+func TestWindow(t *testing.T) {
+	if w, err := Window("1m", []string{"5m", "1h"}, 51); err != nil || w != 53*60 {
+		t.Errorf("Window = %d, %v; want %d", w, err, 53*60)
+	}
+	if w, err := Window("15m", nil, 51); err != nil || w != 0 {
+		t.Errorf("Window sem intervalos = %d, %v; want 0", w, err)
+	}
+	if _, err := Window("15m", []string{"1h", "20m"}, 51); err == nil {
+		t.Error("20m não é múltiplo de 15m e deveria falhar")
+	}
+	if w, err := Window("1m", []string{"4h"}, 50); err != nil || w != 12480 {
+		t.Errorf("Window(4h) = %d, %v; want 12480", w, err)
+	}
+	if _, err := Window("1m", []string{"1d"}, 51); err == nil {
+		t.Errorf("1d sobre 1m passa de MaxWindow=%d e deveria falhar", MaxWindow)
+	}
+}
@@ -48,6 +48,12 @@ func ExtractVolumes(klines []types.Kline) []float64 {
 	return volumes
 }
 
+// ComputeRSI retorna o RSI de period candles a partir do primeiro valor
+// calculável: o resultado tem len(closes)-period elementos e o último
+// corresponde ao último fechamento. Ao contrário das médias (EMA, MACD e
+// média de volume), que têm o tamanho da entrada com zeros antes do primeiro
+// valor, o RSI não é preenchido porque zero é um RSI válido (sobrevenda
+// extrema). Indexe sempre a partir do fim.
 func ComputeRSI(closes []float64, period int) []float64 {
 	var rsi []float64
 	for i := period; i < len(closes); i++ {
@@ -68,53 +74,68 @@ func ComputeRSI(closes []float64, period int) []float64 {
 	return rsi
 }
 
+// ComputeMACD retorna MACD, linha de sinal e histograma alinhados com closes.
+// O MACD vale a partir de longPeriod-1 e o sinal e o histograma a partir de
+// longPeriod+signalPeriod-2; antes disso os valores são zero.
 func ComputeMACD(closes []float64, shortPeriod, longPeriod, signalPeriod int) ([]float64, []float64, []float64) {
-	shortEMA := computeEMA(closes, shortPeriod)
-	longEMA := computeEMA(closes, longPeriod)
+	shortEMA := ComputeEMA(closes, shortPeriod)
+	longEMA := ComputeEMA(closes, longPeriod)
 
-	minLen := int(math.Min(float64(len(shortEMA)), float64(len(longEMA))))
-	macdLine := make([]float64, minLen)
-	for i := 0; i < minLen; i++ {
+	macdLine := make([]float64, len(closes))
+	signalLine := make([]float64, len(closes))
+	histogram := make([]float64, len(closes))
+	start := longPeriod - 1
+	if start < 0 || start >= len(closes) {
+		return macdLine, signalLine, histogram
+	}
+	for i := start; i < len(closes); i++ {
 		macdLine[i] = shortEMA[i] - longEMA[i]
 	}
 
-	signalLine := computeEMA(macdLine, signalPeriod)
-	histogram := make([]float64, len(signalLine))
-	for i := range signalLine {
-		histogram[i] = macdLine[i+len(macdLine)-len(signalLine)] - signalLine[i]
+	signal := ComputeEMA(macdLine[start:], signalPeriod)
+	for i := signalPeriod - 1; i < len(signal); i++ {
+		signalLine[start+i] = signal[i]
+		histogram[start+i] = macdLine[start+i] - signal[i]
 	}
 
 	return macdLine, signalLine, histogram
 }
 
-func ComputeVolumeMA(volumes []float64, period int) float64 {
-	if len(volumes) < period {
-		return 0
+// ComputeVolumeMA retorna a média móvel simples de period volumes, alinhada
+// com volumes e zero antes de period-1
+func ComputeVolumeMA(volumes []float64, period int) []float64 {
+	ma := make([]float64, len(volumes))
+	if period < 1 {
+		return ma
 	}
 	var sum float64
-	for i := len(volumes) - period; i < len(volumes); i++ {
-		sum += volumes[i]
+	for i, v := range volumes {
+		sum += v
+		if i >= period {
+			sum -= volumes[i-period]
+		}
+		if i >= period-1 {
+			ma[i] = sum / float64(period)
+		}
 	}
-	return sum / float64(period)
+	return ma
 }
 
-func computeEMA(data []float64, period int) []float64 {
-	var ema []float64
+// ComputeEMA retorna a média móvel exponencial alinhada com data. O valor em
+// period-1 é a média simples dos primeiros period valores; antes dele é zero.
+func ComputeEMA(data []float64, period int) []float64 {
+	ema := make([]float64, len(data))
+	if period < 1 || len(data) < period {
+		return ema
+	}
+	var sum float64
+	for _, v := range data[:period] {
+		sum += v
+	}
+	ema[period-1] = sum / float64(period)
 	k := 2.0 / (float64(period) + 1.0)
-	for i := 0; i < len(data); i++ {
-		if i < period {
-			continue
-		}
-		if len(ema) == 0 {
-			var sum float64
-			for j := i - period; j < i; j++ {
-				sum += data[j]
-			}
-			ema = append(ema, sum/float64(period))
-		} else {
-			prev := ema[len(ema)-1]
-			ema = append(ema, (data[i]-prev)*k+prev)
-		}
+	for i := period; i < len(data); i++ {
+		ema[i] = (data[i]-ema[i-1])*k + ema[i-1]
 	}
 	return ema
 }
@@ -4,13 +4,15 @@ package indicators
 import (
 	"reflect"
 	"testing"
+
+	"binance-bot/internal/types"
 )
 
 func TestExtractClosePrices(t *testing.T) {
-	klines := [][]interface{}{
-		{0, "1.0", 0, 0, "1.1", 0},
-		{0, "2.0", 0, 0, "2.2", 0},
-		{0, "3.0", 0, 0, "3.3", 0},
+	klines := []types.Kline{
+		{Open: 1.0, Close: 1.1},
+		{Open: 2.0, Close: 2.2},
+		{Open: 3.0, Close: 3.3},
 	}
 	expected := []float64{1.1, 2.2, 3.3}
 	result := ExtractClosePrices(klines)
@@ -20,9 +22,9 @@ func TestExtractClosePrices(t *testing.T) {
 }
 
 func TestExtractVolumes(t *testing.T) {
-	klines := [][]interface{}{
-		{0, 0, 0, 0, 0, "10.0"},
-		{0, 0, 0, 0, 0, "20.0"},
+	klines := []types.Kline{
+		{Volume: 10.0},
+		{Volume: 20.0},
 	}
 	expected := []float64{10.0, 20.0}
 	result := ExtractVolumes(klines)
@@ -53,9 +55,31 @@ func TestComputeMACD(t *testing.T) {
 	}
 }
 
+func TestComputeMACDAlignment(t *testing.T) {
+	prices := []float64{3, 1, 4, 1, 5, 9, 2, 6, 5, 3, 5, 8}
+	macdLine, signalLine, histogram := ComputeMACD(prices, 2, 5, 3)
+	short, long := ComputeEMA(prices, 2), ComputeEMA(prices, 5)
+	for i := 4; i < len(prices); i++ {
+		if macdLine[i] != short[i]-long[i] {
+			t.Errorf("MACD[%d] = %v; want %v", i, macdLine[i], short[i]-long[i])
+		}
+	}
+	// O sinal começa com a média dos três primeiros MACDs válidos
+	if want := (macdLine[4] + macdLine[5] + macdLine[6]) / 3; signalLine[6] != want || signalLine[5] != 0 {
+		t.Errorf("sinal[5..6] = %v, %v; want 0, %v", signalLine[5], signalLine[6], want)
+	}
+	if histogram[11] != macdLine[11]-signalLine[11] {
+		t.Errorf("histograma[11] = %v", histogram[11])
+	}
+}
+
 func TestComputeRSI(t *testing.T) {
 	prices := []float64{1, 2, 1, 2, 1, 2, 1}
 	rsi := ComputeRSI(prices, 3)
+	// Sem preenchimento: só os valores calculáveis, o último no último fechamento
+	if len(rsi) != len(prices)-3 {
+		t.Fatalf("ComputeRSI length = %d; want %d", len(rsi), len(prices)-3)
+	}
 	// RSI values should be between 0 and 100
 	for i, v := range rsi {
 		if v < 0 || v > 100 {
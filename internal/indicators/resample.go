@@ -0,0 +1,202 @@
+package indicators
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"binance-bot/internal/types"
+)
+
+// Os candles semanais da Binance abrem na segunda-feira 00:00 UTC; a época
+// Unix caiu numa quinta-feira
+const weekOffset = 4 * 24 * time.Hour
+
+// IntervalDuration converte um intervalo da Binance ("1m", "15m", "4h", "1d",
+// "1w") em duração. Intervalos mensais não têm duração fixa e não são aceitos.
+func IntervalDuration(interval string) (time.Duration, error) {
+	if len(interval) < 2 {
+		return 0, fmt.Errorf("intervalo inválido: %q", interval)
+	}
+	n, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("intervalo inválido: %q", interval)
+	}
+	var unit time.Duration
+	switch interval[len(interval)-1] {
+	case 'm':
+		unit = time.Minute
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, fmt.Errorf("intervalo não suportado: %q", interval)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// Resample agrega klines do intervalo base no intervalo target, múltiplo
+// dele. Cada candle agregado começa numa fronteira UTC do target (segunda-feira
+// para semanas) e tem a abertura do primeiro candle, a máxima e a mínima do
+// grupo, o fechamento do último e a soma dos volumes; CloseTime é o fim do
+// período, como na Binance. Um primeiro grupo que começa no meio do período
+// é descartado por não ter a abertura real. O último grupo pode estar
+// incompleto (candle em formação): use ResampleClosed para descartá-lo.
+func Resample(klines []types.Kline, base, target string) ([]types.Kline, error) {
+	baseDur, err := IntervalDuration(base)
+	if err != nil {
+		return nil, err
+	}
+	targetDur, err := IntervalDuration(target)
+	if err != nil {
+		return nil, err
+	}
+	if targetDur < baseDur || targetDur%baseDur != 0 {
+		return nil, fmt.Errorf("%s não é múltiplo de %s", target, base)
+	}
+	size := targetDur.Milliseconds()
+	var offset int64
+	if targetDur%(7*24*time.Hour) == 0 {
+		offset = weekOffset.Milliseconds()
+	}
+
+	bucket := func(t int64) int64 { return t - mod(t-offset, size) }
+
+	// Grupo inicial incompleto a descartar
+	partial, skipFirst := int64(0), false
+	if len(klines) > 0 {
+		partial = bucket(klines[0].OpenTime)
+		skipFirst = partial != klines[0].OpenTime
+	}
+
+	var out []types.Kline
+	for _, k := range klines {
+		start := bucket(k.OpenTime)
+		if skipFirst && start == partial {
+			continue
+		}
+		if len(out) > 0 && out[len(out)-1].OpenTime == start {
+			bar := &out[len(out)-1]
+			bar.High = math.Max(bar.High, k.High)
+			bar.Low = math.Min(bar.Low, k.Low)
+			bar.Close = k.Close
+			bar.Volume += k.Volume
+			continue
+		}
+		out = append(out, types.Kline{
+			OpenTime:  start,
+			Open:      k.Open,
+			High:      k.High,
+			Low:       k.Low,
+			Close:     k.Close,
+			Volume:    k.Volume,
+			CloseTime: start + size - 1,
+		})
+	}
+	return out, nil
+}
+
+// ResampleClosed é Resample sem o último candle agregado se ele ainda não
+// fechou, isto é, se o período vai além do último kline base
+func ResampleClosed(klines []types.Kline, base, target string) ([]types.Kline, error) {
+	out, err := Resample(klines, base, target)
+	if err != nil || len(out) == 0 {
+		return out, err
+	}
+	last := klines[len(klines)-1]
+	end := last.CloseTime
+	if end == 0 {
+		baseDur, _ := IntervalDuration(base)
+		end = last.OpenTime + baseDur.Milliseconds() - 1
+	}
+	if out[len(out)-1].CloseTime > end {
+		out = out[:len(out)-1]
+	}
+	return out, nil
+}
+
+// mod é o resto sempre positivo, para horários antes da época
+func mod(a, b int64) int64 {
+	m := a % b
+	if m < 0 {
+		m += b
+	}
+	return m
+}
+
+// HeikinAshi transforma os klines em candles Heikin-Ashi: fechamento médio
+// do candle, abertura no meio do candle Heikin-Ashi anterior e máxima/mínima
+// incluindo os dois. Horários e volume são mantidos.
+func HeikinAshi(klines []types.Kline) []types.Kline {
+	out := make([]types.Kline, len(klines))
+	for i, k := range klines {
+		ha := k
+		ha.Close = (k.Open + k.High + k.Low + k.Close) / 4
+		if i == 0 {
+			ha.Open = (k.Open + k.Close) / 2
+		} else {
+			ha.Open = (out[i-1].Open + out[i-1].Close) / 2
+		}
+		ha.High = math.Max(k.High, math.Max(ha.Open, ha.Close))
+		ha.Low = math.Min(k.Low, math.Min(ha.Open, ha.Close))
+		out[i] = ha
+	}
+	return out
+}
+
+// Renko transforma os fechamentos em tijolos de boxSize: um novo tijolo a
+// cada boxSize na direção atual e, na reversão, só depois de 2*boxSize. Um
+// kline pode formar vários tijolos; eles recebem os horários desse kline e o
+// volume acumulado desde o tijolo anterior fica no primeiro. boxSize não
+// positivo não gera tijolos.
+func Renko(klines []types.Kline, boxSize float64) []types.Kline {
+	if boxSize <= 0 || len(klines) == 0 {
+		return nil
+	}
+	var out []types.Kline
+	last := klines[0].Close // fechamento do último tijolo
+	dir := 0
+	var volume float64
+	brick := func(k types.Kline, open, close float64) {
+		out = append(out, types.Kline{
+			OpenTime:  k.OpenTime,
+			Open:      open,
+			High:      math.Max(open, close),
+			Low:       math.Min(open, close),
+			Close:     close,
+			Volume:    volume,
+			CloseTime: k.CloseTime,
+		})
+		volume = 0
+		last = close
+	}
+	for i, k := range klines {
+		volume += k.Volume
+		if i == 0 {
+			continue
+		}
+		for formed := true; formed; {
+			switch {
+			case dir >= 0 && k.Close >= last+boxSize:
+				brick(k, last, last+boxSize)
+				dir = 1
+			case dir <= 0 && k.Close <= last-boxSize:
+				brick(k, last, last-boxSize)
+				dir = -1
+			case dir > 0 && k.Close <= last-2*boxSize:
+				brick(k, last-boxSize, last-2*boxSize)
+				dir = -1
+			case dir < 0 && k.Close >= last+2*boxSize:
+				brick(k, last+boxSize, last+2*boxSize)
+				dir = 1
+			default:
+				formed = false
+			}
+		}
+	}
+	return out
+}
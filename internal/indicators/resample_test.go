@@ -0,0 +1,125 @@
+// internal/indicators/resample_test.go
+package indicators
+
+import (
+	"testing"
+	"time"
+
+	"binance-bot/internal/types"
+)
+
+// minutos gera klines de 1m a partir de start com os fechamentos informados
+func minutos(start time.Time, closes ...float64) []types.Kline {
+	out := make([]types.Kline, len(closes))
+	for i, c := range closes {
+		open := start.Add(time.Duration(i) * time.Minute).UnixMilli()
+		out[i] = types.Kline{OpenTime: open, Open: c - 0.5, High: c + 1, Low: c - 1, Close: c, Volume: 1, CloseTime: open + 59999}
+	}
+	return out
+}
+
+func TestResample(t *testing.T) {
+	// Começa às 10:03: o grupo 10:00-10:05 está incompleto e é descartado
+	start := time.Date(2024, 3, 1, 10, 3, 0, 0, time.UTC)
+	klines := minutos(start, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13)
+
+	bars, err := Resample(klines, "1m", "5m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 3 {
+		t.Fatalf("%d candles de 5m; want 3: %+v", len(bars), bars)
+	}
+	want := types.Kline{
+		OpenTime:  time.Date(2024, 3, 1, 10, 5, 0, 0, time.UTC).UnixMilli(),
+		Open:      2.5,
+		High:      8,
+		Low:       2,
+		Close:     7,
+		Volume:    5,
+		CloseTime: time.Date(2024, 3, 1, 10, 10, 0, 0, time.UTC).UnixMilli() - 1,
+	}
+	if bars[0] != want {
+		t.Errorf("primeiro candle = %+v; want %+v", bars[0], want)
+	}
+	// 10:15 só tem um candle de 1m: parcial
+	if bars[2].Close != 13 || bars[2].Volume != 1 {
+		t.Errorf("último candle = %+v; want parcial com o fechamento 13", bars[2])
+	}
+
+	closed, _ := ResampleClosed(klines, "1m", "5m")
+	if len(closed) != 2 || closed[1].Close != 12 {
+		t.Errorf("ResampleClosed = %+v; want 2 candles terminando em 12", closed)
+	}
+
+	if _, err := Resample(klines, "5m", "7m"); err == nil {
+		t.Error("7m não é múltiplo de 5m")
+	}
+	if _, err := Resample(klines, "1m", "1M"); err == nil {
+		t.Error("intervalo mensal não deveria ser aceito")
+	}
+}
+
+func TestResampleAlignment(t *testing.T) {
+	day := func(d int) types.Kline {
+		open := time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC).UnixMilli()
+		return types.Kline{OpenTime: open, Open: float64(d), High: float64(d), Low: float64(d), Close: float64(d), CloseTime: open + 86399999}
+	}
+	// 2024-03-04 é segunda-feira
+	var klines []types.Kline
+	for d := 4; d <= 17; d++ {
+		klines = append(klines, day(d))
+	}
+	weeks, err := Resample(klines, "1d", "1w")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(weeks) != 2 || weeks[0].Open != 4 || weeks[0].Close != 10 || weeks[1].Open != 11 {
+		t.Errorf("semanas = %+v; want segunda a domingo", weeks)
+	}
+
+	h4, _ := Resample(minutos(time.Date(2024, 3, 1, 3, 59, 0, 0, time.UTC), 1, 2), "1m", "4h")
+	if len(h4) != 1 || time.UnixMilli(h4[0].OpenTime).UTC().Hour() != 4 {
+		t.Errorf("4h = %+v; want um candle das 04:00", h4)
+	}
+}
+
+func TestHeikinAshi(t *testing.T) {
+	klines := []types.Kline{
+		{Open: 10, High: 12, Low: 9, Close: 11, Volume: 3},
+		{Open: 11, High: 14, Low: 10, Close: 13, Volume: 4},
+	}
+	ha := HeikinAshi(klines)
+	if ha[0].Open != 10.5 || ha[0].Close != 10.5 {
+		t.Errorf("primeiro = %+v; want abertura e fechamento 10.5", ha[0])
+	}
+	if ha[1].Open != 10.5 || ha[1].Close != 12 || ha[1].High != 14 || ha[1].Low != 10 || ha[1].Volume != 4 {
+		t.Errorf("segundo = %+v", ha[1])
+	}
+}
+
+func TestRenko(t *testing.T) {
+	closes := []float64{100, 101, 103.5, 102, 100.5, 99.9}
+	var klines []types.Kline
+	for i, c := range closes {
+		klines = append(klines, types.Kline{OpenTime: int64(i), Close: c, Volume: 1, CloseTime: int64(i)})
+	}
+	bricks := Renko(klines, 1)
+	// 101, 102 e 103 na alta; 102 não reverte, 100.5 reverte até 101 (duas
+	// caixas abaixo do topo) e 99.9 forma o tijolo até 100
+	want := [][2]float64{{100, 101}, {101, 102}, {102, 103}, {102, 101}, {101, 100}}
+	if len(bricks) != len(want) {
+		t.Fatalf("%d tijolos; want %d: %+v", len(bricks), len(want), bricks)
+	}
+	for i, w := range want {
+		if bricks[i].Open != w[0] || bricks[i].Close != w[1] {
+			t.Errorf("tijolo %d = %v → %v; want %v → %v", i, bricks[i].Open, bricks[i].Close, w[0], w[1])
+		}
+	}
+	if bricks[0].Volume != 2 || bricks[1].Volume != 1 || bricks[2].Volume != 0 || bricks[3].Volume != 2 {
+		t.Errorf("volumes = %v %v %v %v; want 2 1 0 2", bricks[0].Volume, bricks[1].Volume, bricks[2].Volume, bricks[3].Volume)
+	}
+	if Renko(klines, 0) != nil {
+		t.Error("boxSize zero não deveria gerar tijolos")
+	}
+}
@@ -7,8 +7,17 @@ import (
 	"time"
 )
 
+// prefix vai na frente do nome de todos os diários
+var prefix string
+
+// SetPrefix define o prefixo dos diários (por exemplo "paper_" no paper
+// trading). Deve ser chamado antes de qualquer registro.
+func SetPrefix(p string) {
+	prefix = p
+}
+
 func LogTrade(symbol, side string, qty, price, saldo float64) {
-	file, err := os.OpenFile("trades.csv", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	file, err := os.OpenFile(prefix+"trades.csv", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		return
 	}
@@ -30,3 +39,57 @@ func LogTrade(symbol, side string, qty, price, saldo float64) {
 func formatFloat(f float64) string {
 	return fmt.Sprintf("%.6f", f)
 }
+
+// LogClosedTrade grava uma posição encerrada no diário de trades fechados,
+// lido por internal/metrics. openedAt zero fica vazio (entrada desconhecida).
+func LogClosedTrade(symbol, side string, qty, entryPrice, exitPrice, pnl float64, openedAt time.Time) {
+	file, err := os.OpenFile(prefix+"closed_trades.csv", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	opened := ""
+	if !openedAt.IsZero() {
+		opened = openedAt.Format(time.RFC3339)
+	}
+	writer.Write([]string{
+		time.Now().Format(time.RFC3339),
+		opened,
+		symbol,
+		side,
+		formatFloat(qty),
+		formatFloat(entryPrice),
+		formatFloat(exitPrice),
+		formatFloat(pnl),
+	})
+}
+
+// LogSignal grava no diário de sinais o sinal que originou uma entrada:
+// estratégia, confiança, stop e alvo sugeridos, motivos e indicadores
+func LogSignal(symbol, strategy, side string, confidence, price, stopLoss, takeProfit float64, reasons, indicators string) {
+	file, err := os.OpenFile(prefix+"signals.csv", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	writer.Write([]string{
+		time.Now().Format(time.RFC3339),
+		symbol,
+		strategy,
+		side,
+		formatFloat(confidence),
+		formatFloat(price),
+		formatFloat(stopLoss),
+		formatFloat(takeProfit),
+		reasons,
+		indicators,
+	})
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoadJournal lê o diário de trades fechados gravado por
+// logger.LogClosedTrade: closed_at, opened_at, symbol, side, qty,
+// entry_price, exit_price, pnl
+func LoadJournal(path string) ([]Trade, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadJournal(f)
+}
+
+// ReadJournal lê o diário de trades fechados de r
+func ReadJournal(r io.Reader) ([]Trade, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	var trades []Trade
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return trades, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rec) < 8 {
+			return nil, fmt.Errorf("linha %d: esperadas 8 colunas, encontradas %d", line, len(rec))
+		}
+		closed, err := time.Parse(time.RFC3339, strings.TrimSpace(rec[0]))
+		if err != nil {
+			if line == 1 {
+				continue // cabeçalho
+			}
+			return nil, fmt.Errorf("linha %d: closed_at: %w", line, err)
+		}
+		var opened time.Time
+		if v := strings.TrimSpace(rec[1]); v != "" {
+			if opened, err = time.Parse(time.RFC3339, v); err != nil {
+				return nil, fmt.Errorf("linha %d: opened_at: %w", line, err)
+			}
+		}
+		pnl, err := strconv.ParseFloat(strings.TrimSpace(rec[7]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("linha %d: pnl: %w", line, err)
+		}
+		trades = append(trades, Trade{
+			Symbol:    rec[2],
+			Side:      rec[3],
+			EntryTime: opened,
+			ExitTime:  closed,
+			PnL:       pnl,
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Dias por ano usados na anualização (cripto negocia todos os dias)
+const daysPerYear = 365
+
+// Trade é uma operação encerrada, vinda do backtest ou do diário ao vivo
+type Trade struct {
+	Symbol    string
+	Side      string
+	EntryTime time.Time
+	ExitTime  time.Time
+	PnL       float64 // líquido de taxas, em USDT
+}
+
+// Point é um ponto da curva de equity
+type Point struct {
+	Time   time.Time
+	Equity float64
+}
+
+// TradeStats são as estatísticas de um conjunto de trades
+type TradeStats struct {
+	Trades       int
+	Wins         int
+	Losses       int
+	NetPnL       float64
+	WinRate      float64 // %
+	ProfitFactor float64 // lucro bruto / prejuízo bruto; +Inf sem perdas
+	Expectancy   float64 // PnL médio por trade
+	AvgWin       float64
+	AvgLoss      float64 // negativo
+}
+
+// Report reúne as métricas de desempenho
+type Report struct {
+	Start, End    time.Time
+	InitialEquity float64
+	FinalEquity   float64
+	TotalReturn   float64 // %
+	CAGR          float64 // %
+	Sharpe        float64 // anualizado, retornos diários
+	Sortino       float64 // anualizado, retornos diários
+	MaxDrawdown   float64 // %
+	MaxDDDuration time.Duration
+	Exposure      float64 // % do período com posição aberta
+	TradeStats
+	PerSymbol map[string]TradeStats
+}
+
+// Compute calcula as métricas. Se equity estiver vazio, a curva é montada a
+// partir de initial e do PnL acumulado dos trades.
+func Compute(trades []Trade, equity []Point, initial float64) Report {
+	if len(equity) == 0 {
+		equity = EquityFromTrades(trades, initial)
+	}
+	r := Report{
+		InitialEquity: initial,
+		FinalEquity:   initial,
+		TradeStats:    tradeStats(trades),
+		PerSymbol:     make(map[string]TradeStats),
+	}
+
+	bySymbol := make(map[string][]Trade)
+	for _, t := range trades {
+		bySymbol[t.Symbol] = append(bySymbol[t.Symbol], t)
+	}
+	for symbol, ts := range bySymbol {
+		r.PerSymbol[symbol] = tradeStats(ts)
+	}
+
+	if len(equity) == 0 {
+		return r
+	}
+	r.Start, r.End = equity[0].Time, equity[len(equity)-1].Time
+	r.FinalEquity = equity[len(equity)-1].Equity
+	if initial > 0 {
+		r.TotalReturn = (r.FinalEquity/initial - 1) * 100
+		if years := r.End.Sub(r.Start).Hours() / 24 / daysPerYear; years > 0 && r.FinalEquity > 0 {
+			r.CAGR = (math.Pow(r.FinalEquity/initial, 1/years) - 1) * 100
+		}
+	}
+	r.MaxDrawdown, r.MaxDDDuration = drawdown(equity)
+	r.Sharpe, r.Sortino = ratios(dailyReturns(equity))
+	r.Exposure = exposure(trades, r.Start, r.End)
+	return r
+}
+
+// EquityFromTrades monta a curva de equity a partir do PnL de cada trade
+func EquityFromTrades(trades []Trade, initial float64) []Point {
+	if len(trades) == 0 {
+		return nil
+	}
+	sorted := append([]Trade(nil), trades...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ExitTime.Before(sorted[j].ExitTime) })
+
+	start := sorted[0].EntryTime
+	for _, t := range sorted {
+		if !t.EntryTime.IsZero() && t.EntryTime.Before(start) {
+			start = t.EntryTime
+		}
+	}
+	if start.IsZero() {
+		start = sorted[0].ExitTime
+	}
+	curve := []Point{{Time: start, Equity: initial}}
+	equity := initial
+	for _, t := range sorted {
+		equity += t.PnL
+		curve = append(curve, Point{Time: t.ExitTime, Equity: equity})
+	}
+	return curve
+}
+
+func tradeStats(trades []Trade) TradeStats {
+	var s TradeStats
+	var grossWin, grossLoss float64
+	for _, t := range trades {
+		s.Trades++
+		s.NetPnL += t.PnL
+		switch {
+		case t.PnL > 0:
+			s.Wins++
+			grossWin += t.PnL
+		case t.PnL < 0:
+			s.Losses++
+			grossLoss += t.PnL
+		}
+	}
+	if s.Trades == 0 {
+		return s
+	}
+	s.WinRate = float64(s.Wins) / float64(s.Trades) * 100
+	s.Expectancy = s.NetPnL / float64(s.Trades)
+	if s.Wins > 0 {
+		s.AvgWin = grossWin / float64(s.Wins)
+	}
+	if s.Losses > 0 {
+		s.AvgLoss = grossLoss / float64(s.Losses)
+	}
+	switch {
+	case grossLoss < 0:
+		s.ProfitFactor = grossWin / -grossLoss
+	case grossWin > 0:
+		s.ProfitFactor = math.Inf(1)
+	}
+	return s
+}
+
+// drawdown retorna a maior queda (%) desde um pico e o maior tempo entre um
+// pico e a recuperação dele (ou o fim da curva)
+func drawdown(equity []Point) (float64, time.Duration) {
+	var maxDD float64
+	var maxDur time.Duration
+	peak, peakTime := equity[0].Equity, equity[0].Time
+	for _, p := range equity {
+		if p.Equity >= peak {
+			if d := p.Time.Sub(peakTime); d > maxDur && p.Equity > peak {
+				maxDur = d
+			}
+			peak, peakTime = p.Equity, p.Time
+			continue
+		}
+		if peak > 0 {
+			maxDD = math.Max(maxDD, (peak-p.Equity)/peak*100)
+		}
+		if d := p.Time.Sub(peakTime); d > maxDur {
+			maxDur = d
+		}
+	}
+	return maxDD, maxDur
+}
+
+// dailyReturns reamostra a curva no último valor de cada dia (UTC) e retorna
+// os retornos diários
+func dailyReturns(equity []Point) []float64 {
+	var days []float64
+	var current time.Time
+	for _, p := range equity {
+		day := p.Time.UTC().Truncate(24 * time.Hour)
+		switch {
+		case len(days) == 0:
+			days = append(days, p.Equity)
+		case day.After(current):
+			// Dias sem pontos repetem o último valor
+			for gap := int(day.Sub(current).Hours()/24) - 1; gap > 0; gap-- {
+				days = append(days, days[len(days)-1])
+			}
+			days = append(days, p.Equity)
+		default:
+			days[len(days)-1] = p.Equity
+		}
+		current = day
+	}
+	var returns []float64
+	for i := 1; i < len(days); i++ {
+		if days[i-1] > 0 {
+			returns = append(returns, days[i]/days[i-1]-1)
+		}
+	}
+	return returns
+}
+
+// ratios calcula Sharpe e Sortino anualizados (taxa livre de risco zero)
+func ratios(returns []float64) (sharpe, sortino float64) {
+	if len(returns) < 2 {
+		return 0, 0
+	}
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	var variance, downside float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+		if r < 0 {
+			downside += r * r
+		}
+	}
+	std := math.Sqrt(variance / float64(len(returns)-1))
+	downDev := math.Sqrt(downside / float64(len(returns)))
+	annual := math.Sqrt(daysPerYear)
+	if std > 0 {
+		sharpe = mean / std * annual
+	}
+	if downDev > 0 {
+		sortino = mean / downDev * annual
+	}
+	return sharpe, sortino
+}
+
+// exposure retorna a % do período em que havia ao menos uma posição aberta
+func exposure(trades []Trade, start, end time.Time) float64 {
+	total := end.Sub(start)
+	if total <= 0 {
+		return 0
+	}
+	type interval struct{ from, to time.Time }
+	var spans []interval
+	for _, t := range trades {
+		if t.EntryTime.IsZero() || !t.ExitTime.After(t.EntryTime) {
+			continue
+		}
+		spans = append(spans, interval{t.EntryTime, t.ExitTime})
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].from.Before(spans[j].from) })
+
+	var covered time.Duration
+	var cur interval
+	for i, s := range spans {
+		if i > 0 && !s.from.After(cur.to) {
+			if s.to.After(cur.to) {
+				cur.to = s.to
+			}
+			continue
+		}
+		if i > 0 {
+			covered += cur.to.Sub(cur.from)
+		}
+		cur = s
+	}
+	if len(spans) > 0 {
+		covered += cur.to.Sub(cur.from)
+	}
+	return math.Min(100, float64(covered)/float64(total)*100)
+}
+
+// String formata o relatório para o terminal ou o Telegram
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📊 Desempenho %s → %s\n", r.Start.Format("2006-01-02"), r.End.Format("2006-01-02"))
+	fmt.Fprintf(&b, "Equity: %.2f → %.2f (%+.2f%%) | CAGR %.2f%%\n", r.InitialEquity, r.FinalEquity, r.TotalReturn, r.CAGR)
+	fmt.Fprintf(&b, "Sharpe %.2f | Sortino %.2f\n", r.Sharpe, r.Sortino)
+	fmt.Fprintf(&b, "Max drawdown %.2f%% | duração %s | exposição %.1f%%\n", r.MaxDrawdown, r.MaxDDDuration.Round(time.Minute), r.Exposure)
+	fmt.Fprintf(&b, "%s\n", r.TradeStats)
+
+	symbols := make([]string, 0, len(r.PerSymbol))
+	for s := range r.PerSymbol {
+		symbols = append(symbols, s)
+	}
+	sort.Strings(symbols)
+	for _, s := range symbols {
+		fmt.Fprintf(&b, "- %s: %s\n", s, r.PerSymbol[s])
+	}
+	return b.String()
+}
+
+// String formata as estatísticas de trades em uma linha
+func (s TradeStats) String() string {
+	return fmt.Sprintf("Trades %d | acertos %.1f%% | PnL %.2f | profit factor %.2f | expectativa %.4f | ganho médio %.4f | perda média %.4f",
+		s.Trades, s.WinRate, s.NetPnL, s.ProfitFactor, s.Expectancy, s.AvgWin, s.AvgLoss)
+}
@@ -0,0 +1,113 @@
+// internal/metrics/metrics_test.go
+package metrics
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func day(n float64) time.Time {
+	return t0.Add(time.Duration(n * 24 * float64(time.Hour)))
+}
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestTradeStats(t *testing.T) {
+	trades := []Trade{
+		{Symbol: "BTCUSDT", EntryTime: day(0), ExitTime: day(1), PnL: 30},
+		{Symbol: "BTCUSDT", EntryTime: day(2), ExitTime: day(3), PnL: -10},
+		{Symbol: "ETHUSDT", EntryTime: day(2.5), ExitTime: day(4), PnL: 10},
+		{Symbol: "ETHUSDT", EntryTime: day(5), ExitTime: day(6), PnL: -10},
+	}
+	r := Compute(trades, nil, 1000)
+
+	if r.Trades != 4 || r.Wins != 2 || r.Losses != 2 {
+		t.Fatalf("contagem errada: %+v", r.TradeStats)
+	}
+	if !approx(r.WinRate, 50) || !approx(r.ProfitFactor, 2) || !approx(r.Expectancy, 5) {
+		t.Errorf("win rate %v, profit factor %v, expectativa %v", r.WinRate, r.ProfitFactor, r.Expectancy)
+	}
+	if !approx(r.AvgWin, 20) || !approx(r.AvgLoss, -10) {
+		t.Errorf("ganho médio %v, perda média %v", r.AvgWin, r.AvgLoss)
+	}
+	if !approx(r.TotalReturn, 2) || !approx(r.FinalEquity, 1020) {
+		t.Errorf("retorno %v%%, equity final %v", r.TotalReturn, r.FinalEquity)
+	}
+	// Posições cobrem os dias 0-1, 2-4 (sobrepostas) e 5-6: 4 de 6 dias
+	if !approx(r.Exposure, 4.0/6*100) {
+		t.Errorf("exposição %v", r.Exposure)
+	}
+
+	btc := r.PerSymbol["BTCUSDT"]
+	if btc.Trades != 2 || !approx(btc.NetPnL, 20) || !approx(btc.ProfitFactor, 3) {
+		t.Errorf("BTCUSDT: %+v", btc)
+	}
+	if eth := r.PerSymbol["ETHUSDT"]; !approx(eth.NetPnL, 0) || !approx(eth.ProfitFactor, 1) {
+		t.Errorf("ETHUSDT: %+v", eth)
+	}
+	if !math.IsInf(Compute(trades[:1], nil, 1000).ProfitFactor, 1) {
+		t.Error("sem perdas o profit factor deveria ser infinito")
+	}
+}
+
+func TestDrawdown(t *testing.T) {
+	equity := []Point{
+		{day(0), 100},
+		{day(1), 120},
+		{day(2), 90},
+		{day(3), 110},
+		{day(4), 125},
+		{day(5), 115},
+	}
+	dd, dur := drawdown(equity)
+	if !approx(dd, 25) {
+		t.Errorf("drawdown %v, esperado 25", dd)
+	}
+	if dur != 3*24*time.Hour {
+		t.Errorf("duração %v, esperado 72h", dur)
+	}
+}
+
+func TestRatios(t *testing.T) {
+	equity := []Point{{day(0), 100}, {day(1), 101}, {day(2), 100.5}, {day(4), 102}}
+	returns := dailyReturns(equity)
+	// O dia 3 sem pontos repete o valor do dia 2
+	if len(returns) != 4 || returns[2] != 0 {
+		t.Fatalf("retornos %v", returns)
+	}
+	sharpe, sortino := ratios(returns)
+	if sharpe <= 0 || sortino <= sharpe {
+		t.Errorf("sharpe %v, sortino %v", sharpe, sortino)
+	}
+	if s, so := ratios([]float64{0.01}); s != 0 || so != 0 {
+		t.Errorf("um retorno só não deveria gerar razões: %v %v", s, so)
+	}
+}
+
+func TestReadJournal(t *testing.T) {
+	data := "closed_at,opened_at,symbol,side,qty,entry_price,exit_price,pnl\n" +
+		"2024-01-02T00:00:00Z,2024-01-01T12:00:00Z,BTCUSDT,BUY,0.010000,40000,40500,4.5\n" +
+		"2024-01-03T00:00:00Z,,ETHUSDT,SELL,0.1,2000,2010,-1.2\n"
+	trades, err := ReadJournal(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) != 2 {
+		t.Fatalf("esperados 2 trades, obtidos %d", len(trades))
+	}
+	if trades[0].Symbol != "BTCUSDT" || trades[0].PnL != 4.5 || !trades[0].EntryTime.Equal(t0.Add(12*time.Hour)) {
+		t.Errorf("trade 0: %+v", trades[0])
+	}
+	if !trades[1].EntryTime.IsZero() || trades[1].PnL != -1.2 {
+		t.Errorf("trade 1: %+v", trades[1])
+	}
+	if _, err := ReadJournal(strings.NewReader("2024-01-02T00:00:00Z,,BTCUSDT\n")); err == nil {
+		t.Error("linha curta deveria falhar")
+	}
+}
@@ -0,0 +1,188 @@
+package protection
+
+import (
+	"fmt"
+	"sync"
+
+	"binance-bot/internal/binance"
+	"binance-bot/internal/trailing"
+)
+
+// Orders são as ordens de proteção abertas na corretora para uma posição
+type Orders struct {
+	Side              string
+	StopOrderID       int64
+	StopPrice         float64
+	TakeProfitOrderID int64
+	TakeProfitPrice   float64
+	TrailingOrderID   int64
+}
+
+// Manager mantém um STOP_MARKET e um TAKE_PROFIT_MARKET por posição, para que
+// ela continue protegida mesmo se o bot cair.
+type Manager struct {
+	mu     sync.Mutex
+	client binance.Exchange
+	orders map[string]*Orders
+}
+
+func NewManager(client binance.Exchange) *Manager {
+	return &Manager{
+		client: client,
+		orders: make(map[string]*Orders),
+	}
+}
+
+// Get retorna as ordens de proteção conhecidas de um símbolo
+func (m *Manager) Get(symbol string) (Orders, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.orders[symbol]
+	if !ok {
+		return Orders{}, false
+	}
+	return *o, true
+}
+
+// Restore registra ordens de proteção que já estão abertas na corretora, por
+// exemplo as carregadas do estado salvo antes de um restart
+func (m *Manager) Restore(symbol string, orders Orders) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o := orders
+	m.orders[symbol] = &o
+}
+
+// Place cria o stop loss e, se takeProfitPrice > 0, o take profit de uma
+// posição do lado side. Se o take profit falhar o stop é mantido.
+func (m *Manager) Place(symbol, side string, stopPrice, takeProfitPrice float64) error {
+	closeSide := trailing.CloseSide(side)
+	stop, err := m.client.PlaceStopMarketOrder(symbol, closeSide, 0, stopPrice)
+	if err != nil {
+		return fmt.Errorf("erro ao criar stop de %s: %w", symbol, err)
+	}
+
+	o := &Orders{Side: side, StopOrderID: stop.OrderID, StopPrice: stopPrice}
+	m.mu.Lock()
+	m.orders[symbol] = o
+	m.mu.Unlock()
+
+	if takeProfitPrice > 0 {
+		tp, err := m.client.PlaceTakeProfitMarketOrder(symbol, closeSide, 0, takeProfitPrice)
+		if err != nil {
+			return fmt.Errorf("erro ao criar take profit de %s: %w", symbol, err)
+		}
+		m.mu.Lock()
+		o.TakeProfitOrderID = tp.OrderID
+		o.TakeProfitPrice = takeProfitPrice
+		m.mu.Unlock()
+	}
+	return nil
+}
+
+// PlaceTrailing cria o trailing stop nativo da corretora para a posição já
+// protegida por Place.
+func (m *Manager) PlaceTrailing(symbol string, quantity, activationPrice, callbackRate float64) error {
+	m.mu.Lock()
+	o, ok := m.orders[symbol]
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%s sem ordens de proteção", symbol)
+	}
+
+	order, err := m.client.PlaceTrailingStopMarketOrder(symbol, trailing.CloseSide(o.Side), quantity, activationPrice, callbackRate)
+	if err != nil {
+		return fmt.Errorf("erro ao criar trailing stop de %s: %w", symbol, err)
+	}
+	m.mu.Lock()
+	o.TrailingOrderID = order.OrderID
+	m.mu.Unlock()
+	return nil
+}
+
+// UpdateStop move o stop para stopPrice se ele proteger mais lucro que o
+// atual. A Binance só aceita um STOP_MARKET closePosition por lado (-4130),
+// então o antigo é cancelado antes de criar o novo. Se o novo falhar, o stop
+// anterior é recolocado; se nem isso for possível a posição fica sem stop
+// (StopOrderID zero) e o erro informa, para quem chama proteger de novo.
+func (m *Manager) UpdateStop(symbol string, stopPrice float64) error {
+	m.mu.Lock()
+	o, ok := m.orders[symbol]
+	if !ok {
+		m.mu.Unlock()
+		return fmt.Errorf("%s sem ordens de proteção", symbol)
+	}
+	current, side, oldID := o.StopPrice, o.Side, o.StopOrderID
+	m.mu.Unlock()
+
+	if oldID != 0 && ((side == "BUY" && stopPrice <= current) || (side == "SELL" && stopPrice >= current)) {
+		return nil
+	}
+
+	if oldID != 0 {
+		if _, err := m.client.CancelOrder(symbol, oldID); err != nil {
+			if binance.IsUnknownOrder(err) {
+				// Stop já executado ou cancelado fora do bot: nada a mover
+				m.setStop(o, 0, 0)
+				return fmt.Errorf("stop %d de %s não está mais aberto: %w", oldID, symbol, err)
+			}
+			return fmt.Errorf("erro ao cancelar stop antigo %d de %s: %w", oldID, symbol, err)
+		}
+	}
+
+	closeSide := trailing.CloseSide(side)
+	stop, err := m.client.PlaceStopMarketOrder(symbol, closeSide, 0, stopPrice)
+	if err == nil {
+		m.setStop(o, stop.OrderID, stopPrice)
+		return nil
+	}
+	if oldID == 0 {
+		return fmt.Errorf("erro ao criar stop de %s: %w", symbol, err)
+	}
+	restored, rerr := m.client.PlaceStopMarketOrder(symbol, closeSide, 0, current)
+	if rerr != nil {
+		m.setStop(o, 0, 0)
+		return fmt.Errorf("%s sem stop: erro ao mover (%v) e ao recolocar em %v (%w)", symbol, err, current, rerr)
+	}
+	m.setStop(o, restored.OrderID, current)
+	return fmt.Errorf("erro ao mover stop de %s, mantido em %v: %w", symbol, current, err)
+}
+
+// setStop registra o stop aberto de uma posição; id zero indica posição sem stop
+func (m *Manager) setStop(o *Orders, id int64, price float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o.StopOrderID = id
+	o.StopPrice = price
+}
+
+// HasStop informa se o símbolo tem um stop de proteção registrado
+func (m *Manager) HasStop(symbol string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.orders[symbol]
+	return ok && o.StopOrderID != 0
+}
+
+// Cancel cancela as ordens de proteção de um símbolo e esquece o registro
+func (m *Manager) Cancel(symbol string) error {
+	m.mu.Lock()
+	o, ok := m.orders[symbol]
+	delete(m.orders, symbol)
+	m.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	var firstErr error
+	for _, id := range []int64{o.StopOrderID, o.TakeProfitOrderID, o.TrailingOrderID} {
+		if id == 0 {
+			continue
+		}
+		_, err := m.client.CancelOrder(symbol, id)
+		if err != nil && !binance.IsUnknownOrder(err) && firstErr == nil {
+			firstErr = fmt.Errorf("erro ao cancelar ordem %d de %s: %w", id, symbol, err)
+		}
+	}
+	return firstErr
+}
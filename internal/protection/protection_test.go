@@ -0,0 +1,69 @@
+// internal/protection/protection_test.go
+package protection
+
+import (
+	"testing"
+	"time"
+
+	"binance-bot/internal/binance"
+	"binance-bot/internal/sim"
+)
+
+// stops retorna os STOP_MARKET abertos do símbolo
+func stops(t *testing.T, e *sim.Exchange, symbol string) []binance.Order {
+	t.Helper()
+	orders, err := e.GetOpenOrders(symbol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []binance.Order
+	for _, o := range orders {
+		if o.Type == binance.OrderTypeStopMarket {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+func TestUpdateStop(t *testing.T) {
+	// A conta simulada recusa um segundo stop closePosition no mesmo lado (-4130)
+	e := sim.New(sim.Config{InitialBalance: 1000, Leverage: 10}, nil)
+	e.Tick("BTCUSDT", 100, time.Now())
+	if _, err := e.PlaceMarketOrder("BTCUSDT", "BUY", 1, false); err != nil {
+		t.Fatal(err)
+	}
+	m := NewManager(e)
+	if err := m.Place("BTCUSDT", "BUY", 95, 110); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.PlaceStopMarketOrder("BTCUSDT", "SELL", 0, 96); err == nil {
+		t.Fatal("a simulação deveria recusar o segundo stop closePosition")
+	}
+
+	if err := m.UpdateStop("BTCUSDT", 97); err != nil {
+		t.Fatal(err)
+	}
+	open := stops(t, e, "BTCUSDT")
+	o, _ := m.Get("BTCUSDT")
+	if len(open) != 1 || open[0].StopPrice != 97 || open[0].OrderID != o.StopOrderID {
+		t.Fatalf("stops abertos = %+v, registrado %+v; want um stop em 97", open, o)
+	}
+
+	// Stop que dispararia na hora falha e o anterior é recolocado
+	if err := m.UpdateStop("BTCUSDT", 101); err == nil {
+		t.Error("stop acima do preço deveria falhar")
+	}
+	open = stops(t, e, "BTCUSDT")
+	o, _ = m.Get("BTCUSDT")
+	if len(open) != 1 || open[0].StopPrice != 97 || o.StopPrice != 97 || !m.HasStop("BTCUSDT") {
+		t.Errorf("stops abertos = %+v, registrado %+v; want o stop de volta em 97", open, o)
+	}
+
+	// Stop que não protege mais lucro não é mexido
+	if err := m.UpdateStop("BTCUSDT", 96); err != nil {
+		t.Error(err)
+	}
+	if o2, _ := m.Get("BTCUSDT"); o2.StopOrderID != o.StopOrderID {
+		t.Errorf("stop mudou de %d para %d", o.StopOrderID, o2.StopOrderID)
+	}
+}
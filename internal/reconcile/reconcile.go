@@ -0,0 +1,229 @@
+package reconcile
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"binance-bot/internal/binance"
+	"binance-bot/internal/protection"
+	"binance-bot/internal/state"
+)
+
+// Report descreve as diferenças entre a corretora e o estado salvo
+type Report struct {
+	Adopted     []string        // posições abertas sem estado salvo, adotadas pelo bot
+	Mismatched  []string        // lado ou quantidade diferente do estado salvo
+	Closed      []string        // estado salvo de posições que não existem mais
+	Foreign     []string        // posições em símbolos que o bot não opera
+	Unprotected []string        // posições do bot sem stop na corretora
+	Orphans     []binance.Order // ordens de proteção sem posição nos símbolos do bot (canceladas)
+	Other       []binance.Order // ordens abertas nos símbolos do bot que não são de proteção (mantidas)
+
+	// Protection são as ordens de proteção encontradas por símbolo
+	Protection map[string]protection.Orders
+
+	positions map[string]binance.Position
+}
+
+// Clean informa se não houve nenhuma divergência
+func (r Report) Clean() bool {
+	return len(r.Adopted)+len(r.Mismatched)+len(r.Closed)+len(r.Foreign)+
+		len(r.Unprotected)+len(r.Orphans)+len(r.Other) == 0
+}
+
+// Summary monta a mensagem enviada ao Telegram
+func (r Report) Summary() string {
+	if r.Clean() {
+		return "✅ Reconciliação: estado salvo confere com a corretora"
+	}
+	var b strings.Builder
+	b.WriteString("🔁 Reconciliação com a corretora")
+	list := func(title string, items []string) {
+		if len(items) > 0 {
+			fmt.Fprintf(&b, "\n%s: %s", title, strings.Join(items, ", "))
+		}
+	}
+	orders := func(title string, items []binance.Order) {
+		for _, o := range items {
+			fmt.Fprintf(&b, "\n%s: %s %s %s #%d", title, o.Symbol, o.Type, o.Side, o.OrderID)
+		}
+	}
+	list("🆕 Posições adotadas", r.Adopted)
+	list("⚠️ Divergentes do estado", r.Mismatched)
+	list("🧹 Fechadas desde a última execução", r.Closed)
+	list("👀 Fora dos símbolos do bot", r.Foreign)
+	list("🛡️ Sem stop na corretora", r.Unprotected)
+	orders("🗑️ Ordem órfã cancelada", r.Orphans)
+	orders("📌 Ordem aberta mantida", r.Other)
+	return b.String()
+}
+
+// Compare cruza as posições e ordens abertas na corretora com o estado salvo.
+// symbols são os símbolos operados pelo bot.
+func Compare(positions []binance.Position, orders []binance.Order, saved map[string]state.Position, symbols []string) Report {
+	r := Report{
+		Protection: make(map[string]protection.Orders),
+		positions:  make(map[string]binance.Position),
+	}
+	traded := make(map[string]bool, len(symbols))
+	for _, s := range symbols {
+		traded[s] = true
+	}
+
+	for _, p := range positions {
+		if p.PositionAmt == 0 {
+			continue
+		}
+		r.positions[p.Symbol] = p
+		if !traded[p.Symbol] {
+			r.Foreign = append(r.Foreign, p.Symbol)
+			continue
+		}
+		s, ok := saved[p.Symbol]
+		switch {
+		case !ok:
+			r.Adopted = append(r.Adopted, p.Symbol)
+		case s.Side != sideOf(p) || !sameQty(s.Quantity, math.Abs(p.PositionAmt)):
+			r.Mismatched = append(r.Mismatched, p.Symbol)
+		}
+	}
+	for symbol := range saved {
+		if _, ok := r.positions[symbol]; !ok {
+			r.Closed = append(r.Closed, symbol)
+		}
+	}
+
+	for _, o := range orders {
+		// Ordens em símbolos que o bot não opera são do usuário e não são tocadas
+		if !traded[o.Symbol] {
+			continue
+		}
+		if !o.ReduceOnly && !o.ClosePosition {
+			r.Other = append(r.Other, o)
+			continue
+		}
+		p, ok := r.positions[o.Symbol]
+		if !ok {
+			r.Orphans = append(r.Orphans, o)
+			continue
+		}
+		if extra, ok := r.adopt(o, sideOf(p)); !ok {
+			r.Orphans = append(r.Orphans, extra)
+		}
+	}
+
+	for symbol := range r.positions {
+		if !traded[symbol] {
+			continue
+		}
+		if o := r.Protection[symbol]; o.StopOrderID == 0 && o.TrailingOrderID == 0 {
+			r.Unprotected = append(r.Unprotected, symbol)
+		}
+	}
+
+	for _, l := range [][]string{r.Adopted, r.Mismatched, r.Closed, r.Foreign, r.Unprotected} {
+		sort.Strings(l)
+	}
+	return r
+}
+
+// adopt registra uma ordem de proteção da posição. Se já houver uma do mesmo
+// tipo, mantém a mais protetora e retorna a outra como órfã.
+func (r *Report) adopt(o binance.Order, side string) (binance.Order, bool) {
+	prot := r.Protection[o.Symbol]
+	prot.Side = side
+	defer func() { r.Protection[o.Symbol] = prot }()
+
+	switch o.Type {
+	case binance.OrderTypeStopMarket:
+		if prot.StopOrderID == 0 {
+			prot.StopOrderID, prot.StopPrice = o.OrderID, o.StopPrice
+			return binance.Order{}, true
+		}
+		tighter := (side == "BUY" && o.StopPrice > prot.StopPrice) || (side == "SELL" && o.StopPrice < prot.StopPrice)
+		if !tighter {
+			return o, false
+		}
+		replaced := binance.Order{Symbol: o.Symbol, OrderID: prot.StopOrderID, Type: o.Type, Side: o.Side, StopPrice: prot.StopPrice}
+		prot.StopOrderID, prot.StopPrice = o.OrderID, o.StopPrice
+		return replaced, false
+	case binance.OrderTypeTakeProfitMarket:
+		if prot.TakeProfitOrderID != 0 {
+			return o, false
+		}
+		prot.TakeProfitOrderID, prot.TakeProfitPrice = o.OrderID, o.StopPrice
+	case binance.OrderTypeTrailingStopMarket:
+		if prot.TrailingOrderID != 0 {
+			return o, false
+		}
+		prot.TrailingOrderID = o.OrderID
+	}
+	return binance.Order{}, true
+}
+
+// Run busca posições e ordens abertas de todos os símbolos, atualiza o estado
+// salvo e cancela as ordens órfãs. Deve rodar antes dos workers começarem.
+func Run(client binance.Exchange, store *state.Store, symbols []string) (Report, error) {
+	positions, err := client.GetPositions("")
+	if err != nil {
+		return Report{}, fmt.Errorf("erro ao buscar posições: %w", err)
+	}
+	orders, err := client.GetOpenOrders("")
+	if err != nil {
+		return Report{}, fmt.Errorf("erro ao buscar ordens abertas: %w", err)
+	}
+	r := Compare(positions, orders, store.Positions(), symbols)
+
+	for _, symbol := range r.Closed {
+		store.RemovePosition(symbol)
+	}
+	for _, symbol := range r.Adopted {
+		p := r.positions[symbol]
+		store.SetPosition(symbol, state.Position{
+			Side:       sideOf(p),
+			EntryPrice: p.EntryPrice,
+			Quantity:   math.Abs(p.PositionAmt),
+			Notional:   math.Abs(p.PositionAmt) * p.MarkPrice,
+			OpenedAt:   time.Now(),
+			Protection: r.Protection[symbol],
+		})
+	}
+	saved := store.Positions()
+	for _, symbol := range r.Mismatched {
+		p, s := r.positions[symbol], saved[symbol]
+		if s.Side != sideOf(p) {
+			s = state.Position{Side: sideOf(p), OpenedAt: time.Now()}
+		}
+		s.EntryPrice = p.EntryPrice
+		s.Quantity = math.Abs(p.PositionAmt)
+		s.Notional = s.Quantity * p.MarkPrice
+		s.Protection = r.Protection[symbol]
+		store.SetPosition(symbol, s)
+	}
+	// As ordens de proteção abertas na corretora são a referência
+	for symbol := range store.Positions() {
+		store.SetProtection(symbol, r.Protection[symbol])
+	}
+
+	for _, o := range r.Orphans {
+		if _, err := client.CancelOrder(o.Symbol, o.OrderID); err != nil && !binance.IsUnknownOrder(err) {
+			log.Printf("⚠️ Erro ao cancelar ordem órfã %d de %s: %v", o.OrderID, o.Symbol, err)
+		}
+	}
+	return r, nil
+}
+
+func sideOf(p binance.Position) string {
+	if p.PositionAmt < 0 {
+		return "SELL"
+	}
+	return "BUY"
+}
+
+func sameQty(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b))
+}
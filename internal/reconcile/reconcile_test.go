@@ -0,0 +1,78 @@
+// internal/reconcile/reconcile_test.go
+package reconcile
+
+import (
+	"reflect"
+	"testing"
+
+	"binance-bot/internal/binance"
+	"binance-bot/internal/state"
+)
+
+func TestCompare(t *testing.T) {
+	symbols := []string{"BTCUSDT", "ETHUSDT", "SOLUSDT", "XRPUSDT"}
+	positions := []binance.Position{
+		{Symbol: "BTCUSDT", PositionAmt: 0.01, EntryPrice: 50000, MarkPrice: 51000}, // conhecida
+		{Symbol: "ETHUSDT", PositionAmt: -2, EntryPrice: 3000, MarkPrice: 2900},     // sem estado
+		{Symbol: "SOLUSDT", PositionAmt: 5, EntryPrice: 100, MarkPrice: 100},        // lado trocado
+		{Symbol: "DOGEUSDT", PositionAmt: 100, EntryPrice: 0.1, MarkPrice: 0.1},     // fora do bot
+		{Symbol: "ADAUSDT", PositionAmt: 0},
+	}
+	saved := map[string]state.Position{
+		"BTCUSDT": {Side: "BUY", Quantity: 0.01},
+		"SOLUSDT": {Side: "SELL", Quantity: 5},
+		"XRPUSDT": {Side: "BUY", Quantity: 10}, // fechada pelo stop enquanto o bot estava fora
+	}
+	orders := []binance.Order{
+		{Symbol: "BTCUSDT", OrderID: 1, Type: binance.OrderTypeStopMarket, Side: "SELL", StopPrice: 49000, ClosePosition: true},
+		{Symbol: "BTCUSDT", OrderID: 2, Type: binance.OrderTypeStopMarket, Side: "SELL", StopPrice: 49500, ClosePosition: true},
+		{Symbol: "BTCUSDT", OrderID: 3, Type: binance.OrderTypeTakeProfitMarket, Side: "SELL", StopPrice: 55000, ClosePosition: true},
+		{Symbol: "XRPUSDT", OrderID: 4, Type: binance.OrderTypeTakeProfitMarket, Side: "SELL", StopPrice: 1, ClosePosition: true},
+		{Symbol: "ETHUSDT", OrderID: 5, Type: binance.OrderTypeLimit, Side: "BUY", Price: 2500},
+		// Proteções manuais do usuário em símbolos fora do bot, com e sem posição
+		{Symbol: "ADAUSDT", OrderID: 6, Type: binance.OrderTypeStopMarket, Side: "SELL", StopPrice: 0.3, ClosePosition: true},
+		{Symbol: "DOGEUSDT", OrderID: 7, Type: binance.OrderTypeStopMarket, Side: "SELL", StopPrice: 0.08, ReduceOnly: true},
+	}
+
+	r := Compare(positions, orders, saved, symbols)
+
+	check := func(name string, got, want []string) {
+		t.Helper()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s = %v; want %v", name, got, want)
+		}
+	}
+	check("Adopted", r.Adopted, []string{"ETHUSDT"})
+	check("Mismatched", r.Mismatched, []string{"SOLUSDT"})
+	check("Closed", r.Closed, []string{"XRPUSDT"})
+	check("Foreign", r.Foreign, []string{"DOGEUSDT"})
+	check("Unprotected", r.Unprotected, []string{"ETHUSDT", "SOLUSDT"})
+
+	// Stop mais apertado fica, o outro e o TP de XRPUSDT são órfãos
+	var orphans []int64
+	for _, o := range r.Orphans {
+		orphans = append(orphans, o.OrderID)
+	}
+	if !reflect.DeepEqual(orphans, []int64{1, 4}) {
+		t.Errorf("Orphans = %v; want [1 4]", orphans)
+	}
+	prot := r.Protection["BTCUSDT"]
+	if prot.StopOrderID != 2 || prot.StopPrice != 49500 || prot.TakeProfitOrderID != 3 || prot.Side != "BUY" {
+		t.Errorf("Protection[BTCUSDT] = %+v", prot)
+	}
+	if len(r.Other) != 1 || r.Other[0].OrderID != 5 {
+		t.Errorf("Other = %+v", r.Other)
+	}
+	if r.Clean() {
+		t.Error("relatório com divergências não deveria ser Clean")
+	}
+}
+
+func TestCompareClean(t *testing.T) {
+	positions := []binance.Position{{Symbol: "BTCUSDT", PositionAmt: -0.5}}
+	saved := map[string]state.Position{"BTCUSDT": {Side: "SELL", Quantity: 0.5}}
+	orders := []binance.Order{{Symbol: "BTCUSDT", OrderID: 9, Type: binance.OrderTypeStopMarket, StopPrice: 60000, ClosePosition: true}}
+	if r := Compare(positions, orders, saved, []string{"BTCUSDT"}); !r.Clean() {
+		t.Errorf("relatório deveria estar limpo: %s", r.Summary())
+	}
+}
@@ -0,0 +1,184 @@
+package risk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"binance-bot/config"
+)
+
+// BreakerState é o estado persistido do circuit breaker
+type BreakerState struct {
+	Day            string    `json:"day"`
+	DayStartEquity float64   `json:"day_start_equity"`
+	DailyPnL       float64   `json:"daily_pnl"`
+	PeakEquity     float64   `json:"peak_equity"`
+	Equity         float64   `json:"equity"` // último equity informado
+	Tripped        bool      `json:"tripped"`
+	Reason         string    `json:"reason,omitempty"`
+	TrippedAt      time.Time `json:"tripped_at,omitempty"`
+}
+
+// CircuitBreaker bloqueia novas entradas quando o prejuízo diário ou o
+// drawdown ultrapassam os limites. Uma vez disparado, só volta com Reset.
+type CircuitBreaker struct {
+	mu     sync.Mutex
+	limits config.RiskConfig
+	path   string
+	state  BreakerState
+	now    func() time.Time
+}
+
+// NewCircuitBreaker carrega o estado salvo em limits.BreakerStateFile, se existir
+func NewCircuitBreaker(limits config.RiskConfig) (*CircuitBreaker, error) {
+	cb := &CircuitBreaker{limits: limits, path: limits.BreakerStateFile, now: time.Now}
+	if cb.path == "" {
+		return cb, nil
+	}
+	data, err := os.ReadFile(cb.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cb, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler estado do circuit breaker: %w", err)
+	}
+	if err := json.Unmarshal(data, &cb.state); err != nil {
+		return nil, fmt.Errorf("estado do circuit breaker inválido: %w", err)
+	}
+	return cb, nil
+}
+
+// UpdateEquity atualiza o pico de equity e verifica drawdown e perda diária.
+// Retorna true se o breaker disparou nesta chamada.
+func (cb *CircuitBreaker) UpdateEquity(equity float64) bool {
+	if equity <= 0 {
+		return false
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.rollDay(equity)
+	cb.state.Equity = equity
+	if equity > cb.state.PeakEquity {
+		cb.state.PeakEquity = equity
+	}
+	tripped := cb.evaluate(equity)
+	cb.persist()
+	return tripped
+}
+
+// RecordTrade soma o PnL realizado de um trade fechado ao acumulado do dia.
+// Se o trade abre um novo dia UTC, o dia começa no último equity informado,
+// anterior ao trade. Retorna true se o breaker disparou nesta chamada.
+func (cb *CircuitBreaker) RecordTrade(pnl float64) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.rollDay(cb.state.Equity)
+	cb.state.DailyPnL += pnl
+	tripped := cb.evaluate(0)
+	cb.persist()
+	return tripped
+}
+
+// Tripped informa se o breaker está disparado e o motivo
+func (cb *CircuitBreaker) Tripped() (bool, string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state.Tripped, cb.state.Reason
+}
+
+// State retorna uma cópia do estado atual
+func (cb *CircuitBreaker) State() BreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// Reset rearma o breaker manualmente. O pico de equity é zerado para não
+// disparar de novo pelo mesmo drawdown.
+func (cb *CircuitBreaker) Reset() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.state.Tripped = false
+	cb.state.Reason = ""
+	cb.state.TrippedAt = time.Time{}
+	cb.state.PeakEquity = 0
+	cb.state.DailyPnL = 0
+	return cb.save()
+}
+
+// rollDay zera o acumulado diário quando muda o dia UTC
+func (cb *CircuitBreaker) rollDay(equity float64) {
+	day := cb.now().UTC().Format("2006-01-02")
+	if cb.state.Day == day {
+		if cb.state.DayStartEquity == 0 {
+			cb.state.DayStartEquity = equity
+		}
+		return
+	}
+	cb.state.Day = day
+	cb.state.DayStartEquity = equity
+	cb.state.DailyPnL = 0
+}
+
+func (cb *CircuitBreaker) evaluate(equity float64) bool {
+	if cb.state.Tripped {
+		return false
+	}
+	l := cb.limits
+	s := &cb.state
+
+	var reason string
+	switch {
+	case l.MaxDailyLoss > 0 && -s.DailyPnL >= l.MaxDailyLoss:
+		reason = fmt.Sprintf("perda diária %.2f USDT >= limite %.2f USDT", -s.DailyPnL, l.MaxDailyLoss)
+	case l.MaxDailyLossPct > 0 && s.DayStartEquity > 0 && -s.DailyPnL/s.DayStartEquity*100 >= l.MaxDailyLossPct:
+		reason = fmt.Sprintf("perda diária %.2f%% >= limite %.2f%%", -s.DailyPnL/s.DayStartEquity*100, l.MaxDailyLossPct)
+	case l.MaxDrawdownPct > 0 && equity > 0 && s.PeakEquity > 0 && (s.PeakEquity-equity)/s.PeakEquity*100 >= l.MaxDrawdownPct:
+		reason = fmt.Sprintf("drawdown %.2f%% >= limite %.2f%% (pico %.2f)", (s.PeakEquity-equity)/s.PeakEquity*100, l.MaxDrawdownPct, s.PeakEquity)
+	default:
+		return false
+	}
+
+	s.Tripped = true
+	s.Reason = reason
+	s.TrippedAt = cb.now().UTC()
+	return true
+}
+
+func (cb *CircuitBreaker) persist() {
+	if err := cb.save(); err != nil {
+		log.Println("⚠️", err)
+	}
+}
+
+// save grava o estado de forma atômica (arquivo temporário + rename)
+func (cb *CircuitBreaker) save() error {
+	if cb.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(cb.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(cb.path), ".breaker-*")
+	if err != nil {
+		return fmt.Errorf("erro ao salvar circuit breaker: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("erro ao salvar circuit breaker: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), cb.path)
+}
@@ -0,0 +1,127 @@
+package risk
+
+import (
+	"fmt"
+	"sync"
+
+	"binance-bot/config"
+)
+
+// Reason identifica o motivo de uma ordem ter sido rejeitada pelo risco
+type Reason string
+
+const (
+	ReasonInvalidOrder     Reason = "INVALID_ORDER"
+	ReasonMaxLeverage      Reason = "MAX_LEVERAGE"
+	ReasonMaxNotional      Reason = "MAX_NOTIONAL_PER_SYMBOL"
+	ReasonMaxExposure      Reason = "MAX_TOTAL_EXPOSURE"
+	ReasonMaxOpenPositions Reason = "MAX_OPEN_POSITIONS"
+)
+
+// Rejection é o erro retornado quando uma ordem viola algum limite
+type Rejection struct {
+	Reason Reason
+	Symbol string
+	Detail string
+}
+
+func (r *Rejection) Error() string {
+	return fmt.Sprintf("%s rejeitada (%s): %s", r.Symbol, r.Reason, r.Detail)
+}
+
+// Order descreve uma ordem candidata a passar pelo motor de risco
+type Order struct {
+	Symbol     string
+	Side       string
+	Quantity   float64
+	Price      float64
+	Leverage   float64
+	ReduceOnly bool
+}
+
+// Notional retorna o valor da ordem em USDT
+func (o Order) Notional() float64 {
+	return o.Quantity * o.Price
+}
+
+// Engine aplica os limites pré-trade e mantém a exposição atual por símbolo
+type Engine struct {
+	mu        sync.Mutex
+	limits    config.RiskConfig
+	positions map[string]float64
+}
+
+func NewEngine(limits config.RiskConfig) *Engine {
+	return &Engine{
+		limits:    limits,
+		positions: make(map[string]float64),
+	}
+}
+
+// UpdatePosition registra o notional aberto de um símbolo. Zero remove a posição.
+func (e *Engine) UpdatePosition(symbol string, notional float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if notional <= 0 {
+		delete(e.positions, symbol)
+		return
+	}
+	e.positions[symbol] = notional
+}
+
+// Exposure retorna a soma do notional de todas as posições abertas
+func (e *Engine) Exposure() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.exposure()
+}
+
+// OpenPositions retorna a quantidade de posições abertas
+func (e *Engine) OpenPositions() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.positions)
+}
+
+// Check valida a ordem contra os limites. Ordens reduce-only sempre passam.
+func (e *Engine) Check(o Order) error {
+	if o.ReduceOnly {
+		return nil
+	}
+	if o.Quantity <= 0 || o.Price <= 0 {
+		return e.reject(o, ReasonInvalidOrder, "quantidade %.6f / preço %.6f inválidos", o.Quantity, o.Price)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	l := e.limits
+	if l.MaxLeverage > 0 && o.Leverage > l.MaxLeverage {
+		return e.reject(o, ReasonMaxLeverage, "alavancagem %.0fx > máx %.0fx", o.Leverage, l.MaxLeverage)
+	}
+
+	current, exists := e.positions[o.Symbol]
+	notional := o.Notional()
+	if l.MaxNotionalPerSymbol > 0 && current+notional > l.MaxNotionalPerSymbol {
+		return e.reject(o, ReasonMaxNotional, "notional %.2f > máx %.2f USDT", current+notional, l.MaxNotionalPerSymbol)
+	}
+	if total := e.exposure() + notional; l.MaxTotalExposure > 0 && total > l.MaxTotalExposure {
+		return e.reject(o, ReasonMaxExposure, "exposição total %.2f > máx %.2f USDT", total, l.MaxTotalExposure)
+	}
+	if l.MaxOpenPositions > 0 && !exists && len(e.positions) >= l.MaxOpenPositions {
+		return e.reject(o, ReasonMaxOpenPositions, "%d posições abertas (máx %d)", len(e.positions), l.MaxOpenPositions)
+	}
+	return nil
+}
+
+func (e *Engine) exposure() float64 {
+	var total float64
+	for _, n := range e.positions {
+		total += n
+	}
+	return total
+}
+
+func (e *Engine) reject(o Order, reason Reason, format string, args ...interface{}) *Rejection {
+	return &Rejection{Reason: reason, Symbol: o.Symbol, Detail: fmt.Sprintf(format, args...)}
+}
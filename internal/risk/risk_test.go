@@ -0,0 +1,110 @@
+// internal/risk/risk_test.go
+package risk
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"binance-bot/config"
+)
+
+func checkReason(t *testing.T, err error, want Reason) {
+	t.Helper()
+	var rej *Rejection
+	if !errors.As(err, &rej) {
+		t.Fatalf("Check = %v; want Rejection %s", err, want)
+	}
+	if rej.Reason != want {
+		t.Errorf("Reason = %s; want %s", rej.Reason, want)
+	}
+}
+
+func TestEngineCheck(t *testing.T) {
+	e := NewEngine(config.RiskConfig{
+		MaxNotionalPerSymbol: 1000,
+		MaxTotalExposure:     1500,
+		MaxOpenPositions:     2,
+		MaxLeverage:          20,
+	})
+
+	ok := Order{Symbol: "BTCUSDT", Side: "BUY", Quantity: 0.01, Price: 50000, Leverage: 10}
+	if err := e.Check(ok); err != nil {
+		t.Fatalf("Check = %v; want nil", err)
+	}
+
+	checkReason(t, e.Check(Order{Symbol: "BTCUSDT", Quantity: 0.01, Price: 50000, Leverage: 50}), ReasonMaxLeverage)
+	checkReason(t, e.Check(Order{Symbol: "BTCUSDT", Quantity: 0.03, Price: 50000, Leverage: 10}), ReasonMaxNotional)
+	checkReason(t, e.Check(Order{Symbol: "BTCUSDT", Quantity: 0, Price: 50000, Leverage: 10}), ReasonInvalidOrder)
+
+	e.UpdatePosition("ETHUSDT", 900)
+	checkReason(t, e.Check(Order{Symbol: "BTCUSDT", Quantity: 0.02, Price: 50000, Leverage: 10}), ReasonMaxExposure)
+
+	e.UpdatePosition("SOLUSDT", 100)
+	checkReason(t, e.Check(Order{Symbol: "XRPUSDT", Quantity: 10, Price: 1, Leverage: 10}), ReasonMaxOpenPositions)
+
+	if err := e.Check(Order{Symbol: "ETHUSDT", Quantity: 100, Price: 3000, Leverage: 125, ReduceOnly: true}); err != nil {
+		t.Errorf("Check reduce-only = %v; want nil", err)
+	}
+
+	e.UpdatePosition("SOLUSDT", 0)
+	if e.OpenPositions() != 1 || e.Exposure() != 900 {
+		t.Errorf("OpenPositions/Exposure = %d/%.2f; want 1/900", e.OpenPositions(), e.Exposure())
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breaker.json")
+	limits := config.RiskConfig{MaxDailyLoss: 50, MaxDrawdownPct: 20, BreakerStateFile: path}
+
+	cb, err := NewCircuitBreaker(limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cb.UpdateEquity(1000)
+	if cb.RecordTrade(-30) {
+		t.Fatal("breaker disparou antes do limite diário")
+	}
+	if !cb.RecordTrade(-25) {
+		t.Fatal("breaker não disparou com perda diária de 55 USDT")
+	}
+
+	// O estado disparado sobrevive a um restart
+	cb, err = NewCircuitBreaker(limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tripped, _ := cb.Tripped(); !tripped {
+		t.Fatal("breaker deveria continuar disparado após recarregar")
+	}
+
+	if err := cb.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	cb.UpdateEquity(1000)
+	if !cb.UpdateEquity(790) {
+		t.Error("breaker não disparou com drawdown de 21%")
+	}
+}
+
+func TestCircuitBreakerNewDayFromTrade(t *testing.T) {
+	cb, err := NewCircuitBreaker(config.RiskConfig{MaxDailyLossPct: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cb.now = func() time.Time { return day }
+	cb.UpdateEquity(1000)
+	cb.UpdateEquity(2000)
+
+	// O primeiro evento do dia seguinte é um fill: a base é o equity atual
+	// (2000), não a abertura do dia anterior (1000)
+	day = day.Add(24 * time.Hour)
+	if cb.RecordTrade(-100) {
+		t.Error("perda de 5% do equity atual não deveria disparar o limite de 8%")
+	}
+	if s := cb.State(); s.DayStartEquity != 2000 || s.DailyPnL != -100 {
+		t.Errorf("estado = %+v; want dia começando em 2000 com PnL -100", s)
+	}
+}
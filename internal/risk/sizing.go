@@ -0,0 +1,246 @@
+package risk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"binance-bot/config"
+	"binance-bot/internal/indicators"
+	"binance-bot/internal/types"
+)
+
+// Fração máxima do saldo disponível que pode virar margem em uma entrada
+const maxMarginFraction = 0.90
+
+var ErrInvalidSizingInput = errors.New("entrada de dimensionamento inválida")
+
+// SizingInput reúne o que os modelos de dimensionamento precisam para calcular a quantidade
+type SizingInput struct {
+	Symbol       string
+	Balance      float64 // saldo USDT disponível
+	Price        float64
+	Leverage     float64
+	StopDistance float64 // distância do stop em preço; zero usa o padrão do modelo
+	Klines       []types.Kline
+}
+
+// maxQty é o teto de quantidade permitido pela margem disponível
+func (in SizingInput) maxQty() float64 {
+	return in.Balance * maxMarginFraction * in.Leverage / in.Price
+}
+
+func (in SizingInput) validate() error {
+	if in.Balance <= 0 || in.Price <= 0 || in.Leverage <= 0 {
+		return fmt.Errorf("%w: saldo %.4f, preço %.4f, alavancagem %.0f", ErrInvalidSizingInput, in.Balance, in.Price, in.Leverage)
+	}
+	return nil
+}
+
+// Sizer calcula a quantidade bruta (sem arredondar para o step) de uma entrada
+type Sizer interface {
+	Name() string
+	Size(in SizingInput) (float64, error)
+}
+
+// FixedNotional abre sempre o mesmo valor em USDT
+type FixedNotional struct {
+	Notional float64
+}
+
+func (s *FixedNotional) Name() string { return "fixed_notional" }
+
+func (s *FixedNotional) Size(in SizingInput) (float64, error) {
+	if err := in.validate(); err != nil {
+		return 0, err
+	}
+	return math.Min(s.Notional/in.Price, in.maxQty()), nil
+}
+
+// FixedFractional arrisca uma porcentagem fixa do saldo até o stop
+type FixedFractional struct {
+	RiskPct float64
+	StopPct float64 // distância padrão do stop em % do preço
+}
+
+func (s *FixedFractional) Name() string { return "fixed_fractional" }
+
+func (s *FixedFractional) Size(in SizingInput) (float64, error) {
+	if err := in.validate(); err != nil {
+		return 0, err
+	}
+	stop := in.StopDistance
+	if stop <= 0 {
+		stop = in.Price * s.StopPct / 100
+	}
+	return riskBasedQty(in, in.Balance*s.RiskPct/100, stop)
+}
+
+// ATRSizer usa o ATR como distância do stop, reduzindo a posição em mercados voláteis
+type ATRSizer struct {
+	RiskPct    float64
+	Period     int
+	Multiplier float64
+}
+
+func (s *ATRSizer) Name() string { return "atr" }
+
+func (s *ATRSizer) Size(in SizingInput) (float64, error) {
+	if err := in.validate(); err != nil {
+		return 0, err
+	}
+	atr := indicators.ComputeATR(in.Klines, s.Period)
+	if atr <= 0 {
+		return 0, fmt.Errorf("%w: ATR(%d) indisponível com %d klines", ErrInvalidSizingInput, s.Period, len(in.Klines))
+	}
+	return riskBasedQty(in, in.Balance*s.RiskPct/100, atr*s.Multiplier)
+}
+
+// Kelly arrisca uma fração do critério de Kelly calculado a partir do
+// histórico de trades. Enquanto não há trades suficientes usa WinRate e Payoff.
+type Kelly struct {
+	Fraction  float64
+	WinRate   float64
+	Payoff    float64
+	MinTrades int
+	StopPct   float64
+
+	mu     sync.Mutex
+	wins   []float64
+	losses []float64
+}
+
+func (s *Kelly) Name() string { return "kelly" }
+
+// Record adiciona o PnL realizado de um trade ao histórico
+func (s *Kelly) Record(pnl float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if pnl > 0 {
+		s.wins = append(s.wins, pnl)
+	} else if pnl < 0 {
+		s.losses = append(s.losses, -pnl)
+	}
+}
+
+// Stats retorna a taxa de acerto e a razão ganho médio / perda média em uso
+func (s *Kelly) Stats() (winRate, payoff float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.wins) + len(s.losses)
+	if n < s.MinTrades || len(s.wins) == 0 || len(s.losses) == 0 {
+		return s.WinRate, s.Payoff
+	}
+	return float64(len(s.wins)) / float64(n), mean(s.wins) / mean(s.losses)
+}
+
+func (s *Kelly) Size(in SizingInput) (float64, error) {
+	if err := in.validate(); err != nil {
+		return 0, err
+	}
+	w, r := s.Stats()
+	if r <= 0 {
+		return 0, nil
+	}
+	f := (w - (1-w)/r) * s.Fraction
+	if f <= 0 {
+		return 0, nil
+	}
+	stop := in.StopDistance
+	if stop <= 0 {
+		stop = in.Price * s.StopPct / 100
+	}
+	return riskBasedQty(in, in.Balance*f, stop)
+}
+
+func riskBasedQty(in SizingInput, riskAmount, stopDistance float64) (float64, error) {
+	if stopDistance <= 0 {
+		return 0, fmt.Errorf("%w: distância do stop %.6f", ErrInvalidSizingInput, stopDistance)
+	}
+	return math.Min(riskAmount/stopDistance, in.maxQty()), nil
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// Sizers resolve o modelo de dimensionamento de cada símbolo
+type Sizers struct {
+	def      Sizer
+	bySymbol map[string]Sizer
+	all      map[string]Sizer
+}
+
+// NewSizers instancia os modelos configurados. Símbolos que usam o mesmo modelo
+// compartilham a mesma instância (e portanto o mesmo histórico do Kelly).
+func NewSizers(cfg config.SizingConfig) (*Sizers, error) {
+	s := &Sizers{bySymbol: make(map[string]Sizer), all: make(map[string]Sizer)}
+	def, err := s.get(cfg.Default, cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.def = def
+	for symbol, name := range cfg.PerSymbol {
+		sizer, err := s.get(name, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", symbol, err)
+		}
+		s.bySymbol[symbol] = sizer
+	}
+	return s, nil
+}
+
+// For retorna o modelo do símbolo ou o padrão
+func (s *Sizers) For(symbol string) Sizer {
+	if sizer, ok := s.bySymbol[symbol]; ok {
+		return sizer
+	}
+	return s.def
+}
+
+// RecordTrade repassa o PnL realizado aos modelos que usam histórico
+func (s *Sizers) RecordTrade(pnl float64) {
+	for _, sizer := range s.all {
+		if r, ok := sizer.(interface{ Record(float64) }); ok {
+			r.Record(pnl)
+		}
+	}
+}
+
+func (s *Sizers) get(name string, cfg config.SizingConfig) (Sizer, error) {
+	if sizer, ok := s.all[name]; ok {
+		return sizer, nil
+	}
+	sizer, err := NewSizer(name, cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.all[name] = sizer
+	return sizer, nil
+}
+
+// NewSizer cria um modelo de dimensionamento pelo nome
+func NewSizer(name string, cfg config.SizingConfig) (Sizer, error) {
+	switch name {
+	case "fixed_notional":
+		return &FixedNotional{Notional: cfg.FixedNotional}, nil
+	case "fixed_fractional":
+		return &FixedFractional{RiskPct: cfg.RiskPct, StopPct: cfg.StopPct}, nil
+	case "atr":
+		return &ATRSizer{RiskPct: cfg.RiskPct, Period: cfg.ATRPeriod, Multiplier: cfg.ATRMultiplier}, nil
+	case "kelly":
+		return &Kelly{
+			Fraction:  cfg.KellyFraction,
+			WinRate:   cfg.KellyWinRate,
+			Payoff:    cfg.KellyPayoff,
+			MinTrades: cfg.KellyMinTrades,
+			StopPct:   cfg.StopPct,
+		}, nil
+	}
+	return nil, fmt.Errorf("modelo de dimensionamento desconhecido: %q", name)
+}
@@ -0,0 +1,87 @@
+// internal/risk/sizing_test.go
+package risk
+
+import (
+	"math"
+	"testing"
+
+	"binance-bot/config"
+	"binance-bot/internal/types"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestSizers(t *testing.T) {
+	in := SizingInput{Symbol: "BTCUSDT", Balance: 1000, Price: 100, Leverage: 10}
+
+	qty, _ := (&FixedNotional{Notional: 500}).Size(in)
+	if !almostEqual(qty, 5) {
+		t.Errorf("FixedNotional = %v; want 5", qty)
+	}
+
+	// 1% de 1000 = 10 USDT de risco com stop a 1% (1 USDT) => 10 unidades
+	qty, _ = (&FixedFractional{RiskPct: 1, StopPct: 1}).Size(in)
+	if !almostEqual(qty, 10) {
+		t.Errorf("FixedFractional = %v; want 10", qty)
+	}
+
+	// Teto: 90% do saldo * alavancagem / preço = 90
+	qty, _ = (&FixedNotional{Notional: 1e9}).Size(in)
+	if !almostEqual(qty, 90) {
+		t.Errorf("FixedNotional com teto = %v; want 90", qty)
+	}
+
+	klines := make([]types.Kline, 20)
+	for i := range klines {
+		klines[i] = types.Kline{High: 101, Low: 99, Close: 100}
+	}
+	in.Klines = klines
+	// ATR = 2, stop = 2*2 = 4 => 10 / 4 = 2.5
+	qty, _ = (&ATRSizer{RiskPct: 1, Period: 14, Multiplier: 2}).Size(in)
+	if !almostEqual(qty, 2.5) {
+		t.Errorf("ATRSizer = %v; want 2.5", qty)
+	}
+
+	// Kelly com W=0.6 e R=2 => f* = 0.4, metade => 20% do saldo = 200 USDT de risco
+	k := &Kelly{Fraction: 0.5, WinRate: 0.6, Payoff: 2, MinTrades: 100, StopPct: 10}
+	qty, _ = k.Size(in)
+	if !almostEqual(qty, 20) {
+		t.Errorf("Kelly = %v; want 20", qty)
+	}
+}
+
+func TestKellyHistory(t *testing.T) {
+	k := &Kelly{Fraction: 1, WinRate: 0.9, Payoff: 3, MinTrades: 4}
+	for _, pnl := range []float64{10, -10, -10, -10} {
+		k.Record(pnl)
+	}
+	w, r := k.Stats()
+	if !almostEqual(w, 0.25) || !almostEqual(r, 1) {
+		t.Errorf("Stats = %v, %v; want 0.25, 1", w, r)
+	}
+	qty, _ := k.Size(SizingInput{Balance: 1000, Price: 100, Leverage: 10, StopDistance: 1})
+	if qty != 0 {
+		t.Errorf("Kelly com expectativa negativa = %v; want 0", qty)
+	}
+}
+
+func TestNewSizersPerSymbol(t *testing.T) {
+	s, err := NewSizers(config.SizingConfig{
+		Default:   "fixed_fractional",
+		PerSymbol: map[string]string{"ETHUSDT": "kelly", "SOLUSDT": "kelly"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.For("BTCUSDT").Name() != "fixed_fractional" || s.For("ETHUSDT").Name() != "kelly" {
+		t.Errorf("For retornou modelos errados")
+	}
+	if s.For("ETHUSDT") != s.For("SOLUSDT") {
+		t.Errorf("símbolos com o mesmo modelo deveriam compartilhar a instância")
+	}
+	if _, err := NewSizers(config.SizingConfig{Default: "martingale"}); err == nil {
+		t.Error("NewSizers com modelo desconhecido deveria falhar")
+	}
+}
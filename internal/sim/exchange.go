@@ -0,0 +1,603 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"binance-bot/internal/binance"
+)
+
+// Códigos de erro da Binance reproduzidos pela simulação
+const (
+	codeImmediateTrigger    = -2021
+	codeReduceOnlyRejected  = -2022
+	codeClosePositionExists = -4130
+)
+
+// Config define a conta simulada
+type Config struct {
+	InitialBalance       float64
+	Leverage             float64
+	TakerFeePct          float64 // % do notional em ordens a mercado e gatilhos
+	MakerFeePct          float64 // % do notional em ordens limitadas
+	SlippagePct          float64 // % de piora do preço em execuções a mercado
+	FundingRatePct       float64 // % do notional por período; positivo: comprados pagam
+	FundingInterval      time.Duration
+	MaintenanceMarginPct float64 // % do notional; abaixo disso a posição é liquidada
+}
+
+// DefaultConfig usa as taxas padrão da Binance Futures (VIP 0)
+func DefaultConfig(balance, leverage float64) Config {
+	return Config{
+		InitialBalance:       balance,
+		Leverage:             leverage,
+		TakerFeePct:          0.05,
+		MakerFeePct:          0.02,
+		SlippagePct:          0.01,
+		FundingRatePct:       0.01,
+		FundingInterval:      8 * time.Hour,
+		MaintenanceMarginPct: 0.4,
+	}
+}
+
+// MarketData é a fonte de klines, mark price e regras dos símbolos. Sem ela
+// os preços vêm apenas de Tick e as regras são livres.
+type MarketData interface {
+	GetKlines(symbol, interval string, limit int) ([][]interface{}, error)
+	GetMarkPrice(symbol string) (float64, error)
+	SymbolRules(symbol string) (binance.SymbolRules, error)
+}
+
+// Exchange é uma corretora de futuros USDT-M simulada em memória, em modo
+// one-way e margem isolada por posição. As execuções usam o último mark
+// price; ordens condicionais e liquidações são avaliadas a cada Tick.
+type Exchange struct {
+	mu     sync.Mutex
+	cfg    Config
+	market MarketData
+	path   string // arquivo da conta; vazio não persiste
+	dirty  bool
+
+	wallet      float64 // saldo realizado: depósito + PnL - taxas - funding
+	positions   map[string]*position
+	orders      map[int64]*order
+	prices      map[string]float64
+	funding     map[string]float64 // taxa de funding (%) informada por símbolo
+	nextID      int64
+	now         time.Time // horário do último Tick
+	lastFunding time.Time
+
+	fills chan binance.Fill
+}
+
+type position struct {
+	amt        float64 // positivo comprado, negativo vendido
+	entryPrice float64
+}
+
+// order é uma ordem aberta. Ordens trailing guardam a ativação e o extremo
+// de preço desde que foram ativadas.
+type order struct {
+	binance.Order
+	activation float64
+	callback   float64
+	active     bool
+	extreme    float64
+}
+
+// New cria a conta simulada. market pode ser nil.
+func New(cfg Config, market MarketData) *Exchange {
+	if cfg.Leverage <= 0 {
+		cfg.Leverage = 1
+	}
+	return &Exchange{
+		cfg:       cfg,
+		market:    market,
+		wallet:    cfg.InitialBalance,
+		positions: make(map[string]*position),
+		orders:    make(map[int64]*order),
+		prices:    make(map[string]float64),
+		funding:   make(map[string]float64),
+		fills:     make(chan binance.Fill, 256),
+	}
+}
+
+var _ binance.Exchange = (*Exchange)(nil)
+
+// Fills entrega cada execução, no mesmo formato agregado do user data stream
+func (e *Exchange) Fills() <-chan binance.Fill {
+	return e.fills
+}
+
+// Tick atualiza o mark price de symbol no instante at: cobra o funding
+// vencido, dispara as ordens condicionais atingidas e liquida a posição se o
+// preço passou do preço de liquidação
+func (e *Exchange) Tick(symbol string, price float64, at time.Time) {
+	if price <= 0 {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	defer e.flush()
+	e.prices[symbol] = price
+	if at.After(e.now) {
+		e.now = at
+	}
+	e.applyFunding()
+	e.triggerOrders(symbol, price)
+	e.checkLiquidation(symbol, price)
+}
+
+// SetFundingRate define a taxa de funding (%) de symbol, no lugar de
+// Config.FundingRatePct. No paper trading vem do stream de mark price.
+func (e *Exchange) SetFundingRate(symbol string, ratePct float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.funding[symbol] = ratePct
+}
+
+// GetUSDTBalance retorna o saldo disponível: equity menos a margem inicial
+// das posições abertas
+func (e *Exchange) GetUSDTBalance() (float64, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.available(), nil
+}
+
+// GetAccountEquity retorna o saldo da carteira mais o PnL não realizado
+func (e *Exchange) GetAccountEquity() (float64, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.equity(), nil
+}
+
+// GetMarkPrice consulta a fonte de mercado (atualizando a simulação) ou
+// retorna o último preço recebido por Tick
+func (e *Exchange) GetMarkPrice(symbol string) (float64, error) {
+	if e.market != nil {
+		price, err := e.market.GetMarkPrice(symbol)
+		if err != nil {
+			return 0, err
+		}
+		e.Tick(symbol, price, time.Now())
+		return price, nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	price, ok := e.prices[symbol]
+	if !ok {
+		return 0, fmt.Errorf("sem preço simulado para %s", symbol)
+	}
+	return price, nil
+}
+
+// GetKlines repassa a consulta à fonte de mercado
+func (e *Exchange) GetKlines(symbol, interval string, limit int) ([][]interface{}, error) {
+	if e.market == nil {
+		return nil, errors.New("corretora simulada sem fonte de klines")
+	}
+	return e.market.GetKlines(symbol, interval, limit)
+}
+
+// SymbolRules repassa a consulta à fonte de mercado; sem ela não há filtros
+func (e *Exchange) SymbolRules(symbol string) (binance.SymbolRules, error) {
+	if e.market == nil {
+		return binance.SymbolRules{Symbol: symbol, Status: "TRADING"}, nil
+	}
+	return e.market.SymbolRules(symbol)
+}
+
+// GetPositions retorna as posições abertas de um símbolo, ou de todas se
+// symbol for vazio
+func (e *Exchange) GetPositions(symbol string) ([]binance.Position, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var out []binance.Position
+	for s, p := range e.positions {
+		if symbol != "" && s != symbol {
+			continue
+		}
+		mark := e.prices[s]
+		out = append(out, binance.Position{
+			Symbol:           s,
+			PositionSide:     "BOTH",
+			PositionAmt:      p.amt,
+			EntryPrice:       p.entryPrice,
+			MarkPrice:        mark,
+			UnrealizedProfit: (mark - p.entryPrice) * p.amt,
+			LiquidationPrice: e.liquidationPrice(p),
+			Leverage:         e.cfg.Leverage,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+	return out, nil
+}
+
+// GetOpenOrders lista as ordens abertas de um símbolo, ou de todos se symbol for vazio
+func (e *Exchange) GetOpenOrders(symbol string) ([]binance.Order, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var out []binance.Order
+	for _, o := range e.sortedOrders(symbol) {
+		out = append(out, o.Order)
+	}
+	return out, nil
+}
+
+// PlaceMarketOrder executa na hora ao mark price com slippage
+func (e *Exchange) PlaceMarketOrder(symbol, side string, quantity float64, reduceOnly bool) (*binance.Order, error) {
+	if e.market != nil && !e.hasPrice(symbol) {
+		if _, err := e.GetMarkPrice(symbol); err != nil {
+			return nil, err
+		}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	defer e.flush()
+	price, ok := e.prices[symbol]
+	if !ok {
+		return nil, fmt.Errorf("sem preço simulado para %s", symbol)
+	}
+	o := e.newOrder(symbol, side, binance.OrderTypeMarket, quantity)
+	o.ReduceOnly = reduceOnly
+	if err := e.execute(&o.Order, MarketPrice(price, side, e.cfg.SlippagePct), e.cfg.TakerFeePct); err != nil {
+		return nil, err
+	}
+	return &o.Order, nil
+}
+
+// PlaceLimitOrder cria uma ordem LIMIT, executada ao preço limite quando o
+// mark price o atinge
+func (e *Exchange) PlaceLimitOrder(symbol, side string, quantity, price float64, timeInForce string) (*binance.Order, error) {
+	if timeInForce == "" {
+		timeInForce = binance.TimeInForceGTC
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	defer e.flush()
+	o := e.newOrder(symbol, side, binance.OrderTypeLimit, quantity)
+	o.Price = price
+	o.TimeInForce = timeInForce
+	e.orders[o.OrderID] = o
+	e.dirty = true
+	if mark, ok := e.prices[symbol]; ok {
+		e.triggerOrders(symbol, mark)
+	}
+	return &o.Order, nil
+}
+
+// PlaceStopMarketOrder cria um STOP_MARKET. Com quantity zero fecha a
+// posição inteira; senão é reduce-only.
+func (e *Exchange) PlaceStopMarketOrder(symbol, side string, quantity, stopPrice float64) (*binance.Order, error) {
+	return e.placeTrigger(symbol, side, binance.OrderTypeStopMarket, quantity, stopPrice)
+}
+
+// PlaceTakeProfitMarketOrder cria um TAKE_PROFIT_MARKET. Com quantity zero
+// fecha a posição inteira; senão é reduce-only.
+func (e *Exchange) PlaceTakeProfitMarketOrder(symbol, side string, quantity, stopPrice float64) (*binance.Order, error) {
+	return e.placeTrigger(symbol, side, binance.OrderTypeTakeProfitMarket, quantity, stopPrice)
+}
+
+func (e *Exchange) placeTrigger(symbol, side, orderType string, quantity, stopPrice float64) (*binance.Order, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	defer e.flush()
+	o := e.newOrder(symbol, side, orderType, quantity)
+	o.StopPrice = stopPrice
+	o.ReduceOnly = quantity > 0
+	o.ClosePosition = quantity == 0
+	if mark, ok := e.prices[symbol]; ok && triggered(o, mark) {
+		return nil, &binance.APIError{StatusCode: http.StatusBadRequest, Code: codeImmediateTrigger, Msg: "Order would immediately trigger."}
+	}
+	// Como na Binance, só um closePosition por tipo e lado em cada símbolo
+	if o.ClosePosition {
+		for _, other := range e.orders {
+			if other.Symbol == symbol && other.ClosePosition && other.Type == orderType && other.Side == side {
+				return nil, &binance.APIError{StatusCode: http.StatusBadRequest, Code: codeClosePositionExists, Msg: "An open stop or take profit order with GTE and closePosition in the direction is existing."}
+			}
+		}
+	}
+	e.orders[o.OrderID] = o
+	e.dirty = true
+	return &o.Order, nil
+}
+
+// PlaceTrailingStopMarketOrder cria um TRAILING_STOP_MARKET reduce-only que é
+// ativado em activationPrice e dispara após recuo de callbackRate% do extremo
+func (e *Exchange) PlaceTrailingStopMarketOrder(symbol, side string, quantity, activationPrice, callbackRate float64) (*binance.Order, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	defer e.flush()
+	o := e.newOrder(symbol, side, binance.OrderTypeTrailingStopMarket, quantity)
+	o.ReduceOnly = true
+	o.activation = activationPrice
+	o.callback = callbackRate
+	e.orders[o.OrderID] = o
+	e.dirty = true
+	return &o.Order, nil
+}
+
+// CancelOrder cancela uma ordem aberta
+func (e *Exchange) CancelOrder(symbol string, orderID int64) (*binance.Order, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	defer e.flush()
+	o, ok := e.orders[orderID]
+	if !ok || o.Symbol != symbol {
+		return nil, &binance.APIError{StatusCode: http.StatusBadRequest, Code: binance.CodeUnknownOrder, Msg: "Unknown order sent."}
+	}
+	delete(e.orders, orderID)
+	e.dirty = true
+	o.Status = binance.OrderStatusCanceled
+	o.UpdateTime = e.clock().UnixMilli()
+	return &o.Order, nil
+}
+
+func (e *Exchange) newOrder(symbol, side, orderType string, quantity float64) *order {
+	e.nextID++
+	return &order{Order: binance.Order{
+		Symbol:     symbol,
+		OrderID:    e.nextID,
+		Side:       side,
+		Type:       orderType,
+		Status:     binance.OrderStatusNew,
+		OrigQty:    quantity,
+		UpdateTime: e.clock().UnixMilli(),
+	}}
+}
+
+// clock é o horário do último Tick, ou o relógio local antes do primeiro
+func (e *Exchange) clock() time.Time {
+	if e.now.IsZero() {
+		return time.Now()
+	}
+	return e.now
+}
+
+// execute aplica uma execução à posição do símbolo, cobrando a taxa e
+// realizando o PnL da parte que reduz a posição
+func (e *Exchange) execute(o *binance.Order, price, feePct float64) error {
+	pos := e.positions[o.Symbol]
+	qty := o.OrigQty
+	dir := 1.0
+	if o.Side == "SELL" {
+		dir = -1
+	}
+	reducing := pos != nil && pos.amt*dir < 0
+
+	if o.ReduceOnly || o.ClosePosition {
+		if !reducing {
+			return &binance.APIError{StatusCode: http.StatusBadRequest, Code: codeReduceOnlyRejected, Msg: "ReduceOnly Order is rejected."}
+		}
+		if o.ClosePosition || qty > math.Abs(pos.amt) {
+			qty = math.Abs(pos.amt)
+		}
+	}
+	if qty <= 0 {
+		return fmt.Errorf("quantidade inválida %v", qty)
+	}
+
+	closeQty, openQty := 0.0, qty
+	if reducing {
+		closeQty = math.Min(qty, math.Abs(pos.amt))
+		openQty = qty - closeQty
+	}
+	fee := Fee(price, qty, feePct)
+	if openQty > 0 {
+		required := openQty*price/e.cfg.Leverage + fee
+		if available := e.available(); required > available {
+			return &binance.APIError{StatusCode: http.StatusBadRequest, Code: binance.CodeMarginInsufficient, Msg: "Margin is insufficient."}
+		}
+	}
+
+	var realized float64
+	if closeQty > 0 {
+		posSide := "BUY"
+		if pos.amt < 0 {
+			posSide = "SELL"
+		}
+		realized = PnL(posSide, pos.entryPrice, price, closeQty)
+		pos.amt += closeQty * dir
+		if math.Abs(pos.amt) < 1e-12 {
+			delete(e.positions, o.Symbol)
+			pos = nil
+		}
+	}
+	if openQty > 0 {
+		if pos == nil {
+			pos = &position{}
+			e.positions[o.Symbol] = pos
+		}
+		notional := math.Abs(pos.amt)*pos.entryPrice + openQty*price
+		pos.amt += openQty * dir
+		pos.entryPrice = notional / math.Abs(pos.amt)
+	}
+	e.wallet += realized - fee
+	e.dirty = true
+
+	o.Status = binance.OrderStatusFilled
+	o.ExecutedQty = qty
+	o.AvgPrice = price
+	o.UpdateTime = e.clock().UnixMilli()
+	e.publish(binance.Fill{
+		Symbol:          o.Symbol,
+		OrderID:         o.OrderID,
+		Side:            o.Side,
+		Quantity:        qty,
+		AvgPrice:        price,
+		RealizedPnL:     realized,
+		Commission:      fee,
+		CommissionAsset: "USDT",
+		ReduceOnly:      o.ReduceOnly || o.ClosePosition,
+	})
+	return nil
+}
+
+func (e *Exchange) publish(f binance.Fill) {
+	select {
+	case e.fills <- f:
+	default:
+		log.Printf("⚠️ Execução simulada %d de %s descartada: canal cheio", f.OrderID, f.Symbol)
+	}
+}
+
+// triggerOrders executa as ordens abertas de symbol atingidas por price
+func (e *Exchange) triggerOrders(symbol string, price float64) {
+	for _, o := range e.sortedOrders(symbol) {
+		if o.Type == binance.OrderTypeTrailingStopMarket {
+			e.trackTrailing(o, price)
+		}
+		if !triggered(o, price) {
+			continue
+		}
+		delete(e.orders, o.OrderID)
+		e.dirty = true
+		fill, fee := MarketPrice(price, o.Side, e.cfg.SlippagePct), e.cfg.TakerFeePct
+		if o.Type == binance.OrderTypeLimit {
+			fill, fee = o.Price, e.cfg.MakerFeePct
+		}
+		if err := e.execute(&o.Order, fill, fee); err != nil {
+			// Reduce-only sem posição a reduzir expira, como na Binance
+			o.Status = binance.OrderStatusExpired
+			log.Printf("⚠️ Ordem simulada %s %d de %s expirou: %v", o.Type, o.OrderID, symbol, err)
+			continue
+		}
+		log.Printf("🧪 Ordem simulada %s %s %s executada: %v @ %.4f", o.Type, o.Side, symbol, o.ExecutedQty, o.AvgPrice)
+	}
+}
+
+// trackTrailing ativa o trailing ao atingir a ativação e acompanha o extremo
+func (e *Exchange) trackTrailing(o *order, price float64) {
+	if !o.active {
+		// Um trailing de venda protege uma compra: ativa quando o preço sobe até a ativação
+		if o.activation > 0 && ((o.Side == "SELL" && price < o.activation) || (o.Side == "BUY" && price > o.activation)) {
+			return
+		}
+		o.active = true
+		o.extreme = price
+		e.dirty = true
+	}
+	if (o.Side == "SELL" && price > o.extreme) || (o.Side == "BUY" && price < o.extreme) {
+		o.extreme = price
+		e.dirty = true
+	}
+}
+
+// triggered informa se a ordem dispara ao preço informado
+func triggered(o *order, price float64) bool {
+	buy := o.Side == "BUY"
+	switch o.Type {
+	case binance.OrderTypeLimit:
+		return (buy && price <= o.Price) || (!buy && price >= o.Price)
+	case binance.OrderTypeStopMarket:
+		return (buy && price >= o.StopPrice) || (!buy && price <= o.StopPrice)
+	case binance.OrderTypeTakeProfitMarket:
+		return (buy && price <= o.StopPrice) || (!buy && price >= o.StopPrice)
+	case binance.OrderTypeTrailingStopMarket:
+		if !o.active {
+			return false
+		}
+		if buy {
+			return price >= o.extreme*(1+o.callback/100)
+		}
+		return price <= o.extreme*(1-o.callback/100)
+	}
+	return false
+}
+
+// applyFunding cobra os períodos de funding vencidos até o último Tick
+func (e *Exchange) applyFunding() {
+	interval := e.cfg.FundingInterval
+	if interval <= 0 {
+		return
+	}
+	if e.lastFunding.IsZero() {
+		e.lastFunding = e.now.Truncate(interval)
+		e.dirty = true
+		return
+	}
+	for !e.lastFunding.Add(interval).After(e.now) {
+		e.lastFunding = e.lastFunding.Add(interval)
+		e.dirty = true
+		for symbol, p := range e.positions {
+			rate, ok := e.funding[symbol]
+			if !ok {
+				rate = e.cfg.FundingRatePct
+			}
+			payment := p.amt * e.prices[symbol] * rate / 100
+			e.wallet -= payment
+			log.Printf("🧪 Funding simulado %s: %.4f USDT", symbol, -payment)
+		}
+	}
+}
+
+// checkLiquidation fecha a posição ao preço de liquidação se ele foi atingido
+func (e *Exchange) checkLiquidation(symbol string, price float64) {
+	p, ok := e.positions[symbol]
+	if !ok {
+		return
+	}
+	liq := e.liquidationPrice(p)
+	if (p.amt > 0 && price > liq) || (p.amt < 0 && price < liq) {
+		return
+	}
+	side := "SELL"
+	if p.amt < 0 {
+		side = "BUY"
+	}
+	o := e.newOrder(symbol, side, binance.OrderTypeMarket, math.Abs(p.amt))
+	o.ClosePosition = true
+	e.execute(&o.Order, liq, e.cfg.TakerFeePct)
+	log.Printf("💀 Posição simulada de %s liquidada @ %.4f", symbol, liq)
+}
+
+// liquidationPrice é o preço em que a margem isolada da posição cai à margem
+// de manutenção
+func (e *Exchange) liquidationPrice(p *position) float64 {
+	mmr := e.cfg.MaintenanceMarginPct / 100
+	if p.amt > 0 {
+		return p.entryPrice * (1 - 1/e.cfg.Leverage + mmr)
+	}
+	return p.entryPrice * (1 + 1/e.cfg.Leverage - mmr)
+}
+
+func (e *Exchange) hasPrice(symbol string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, ok := e.prices[symbol]
+	return ok
+}
+
+func (e *Exchange) equity() float64 {
+	equity := e.wallet
+	for symbol, p := range e.positions {
+		equity += (e.prices[symbol] - p.entryPrice) * p.amt
+	}
+	return equity
+}
+
+func (e *Exchange) available() float64 {
+	available := e.equity()
+	for symbol, p := range e.positions {
+		available -= math.Abs(p.amt) * e.prices[symbol] / e.cfg.Leverage
+	}
+	return math.Max(0, available)
+}
+
+// sortedOrders retorna as ordens abertas de symbol (ou todas) por ordem de criação
+func (e *Exchange) sortedOrders(symbol string) []*order {
+	var out []*order
+	for _, o := range e.orders {
+		if symbol == "" || o.Symbol == symbol {
+			out = append(out, o)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].OrderID < out[j].OrderID })
+	return out
+}
@@ -0,0 +1,170 @@
+// internal/sim/exchange_test.go
+package sim
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"binance-bot/internal/binance"
+)
+
+var t0 = time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)
+
+func newTestExchange() *Exchange {
+	return New(Config{InitialBalance: 1000, Leverage: 10, TakerFeePct: 0.1, MaintenanceMarginPct: 0.5}, nil)
+}
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestMarketOrderPnLAndFees(t *testing.T) {
+	e := newTestExchange()
+	e.Tick("BTCUSDT", 100, t0)
+
+	if _, err := e.PlaceMarketOrder("BTCUSDT", "BUY", 50, false); err != nil {
+		t.Fatal(err)
+	}
+	// Margem 500, taxa 5
+	if avail, _ := e.GetUSDTBalance(); !approx(avail, 495) {
+		t.Errorf("disponível %v, esperado 495", avail)
+	}
+
+	e.Tick("BTCUSDT", 110, t0.Add(time.Minute))
+	if eq, _ := e.GetAccountEquity(); !approx(eq, 1495) {
+		t.Errorf("equity %v, esperado 1495", eq)
+	}
+	positions, _ := e.GetPositions("BTCUSDT")
+	if len(positions) != 1 || positions[0].PositionAmt != 50 || !approx(positions[0].LiquidationPrice, 90.5) {
+		t.Fatalf("posição inesperada: %+v", positions)
+	}
+
+	if _, err := e.PlaceMarketOrder("BTCUSDT", "SELL", 80, true); err != nil {
+		t.Fatal(err)
+	}
+	if positions, _ := e.GetPositions(""); len(positions) != 0 {
+		t.Errorf("reduce-only deveria fechar só a posição: %+v", positions)
+	}
+	// +500 de PnL, taxas 5 + 5.5
+	if eq, _ := e.GetAccountEquity(); !approx(eq, 1489.5) {
+		t.Errorf("equity final %v, esperado 1489.5", eq)
+	}
+
+	entry, exit := <-e.Fills(), <-e.Fills()
+	if entry.Closing() || !exit.Closing() || !approx(exit.RealizedPnL, 500) || !approx(exit.Commission, 5.5) {
+		t.Errorf("execuções inesperadas: %+v %+v", entry, exit)
+	}
+}
+
+func TestOrderRejections(t *testing.T) {
+	e := newTestExchange()
+	e.Tick("BTCUSDT", 100, t0)
+
+	_, err := e.PlaceMarketOrder("BTCUSDT", "BUY", 200, false)
+	if !binance.IsInsufficientMargin(err) {
+		t.Errorf("esperado margem insuficiente, obtido %v", err)
+	}
+	if _, err := e.PlaceMarketOrder("BTCUSDT", "SELL", 1, true); err == nil {
+		t.Error("reduce-only sem posição deveria falhar")
+	}
+	if _, err := e.CancelOrder("BTCUSDT", 42); !binance.IsUnknownOrder(err) {
+		t.Errorf("esperada ordem desconhecida, obtido %v", err)
+	}
+	if _, err := e.PlaceStopMarketOrder("BTCUSDT", "SELL", 0, 101); err == nil {
+		t.Error("stop que dispararia na hora deveria falhar")
+	}
+}
+
+func TestProtectiveOrders(t *testing.T) {
+	e := newTestExchange()
+	e.Tick("BTCUSDT", 100, t0)
+	e.PlaceMarketOrder("BTCUSDT", "BUY", 10, false)
+
+	stop, _ := e.PlaceStopMarketOrder("BTCUSDT", "SELL", 0, 95)
+	trail, _ := e.PlaceTrailingStopMarketOrder("BTCUSDT", "SELL", 10, 105, 2)
+
+	e.Tick("BTCUSDT", 104, t0.Add(time.Minute))
+	e.Tick("BTCUSDT", 110, t0.Add(2*time.Minute))
+	e.Tick("BTCUSDT", 108, t0.Add(3*time.Minute)) // recuo de 1.8%: não dispara
+	if orders, _ := e.GetOpenOrders("BTCUSDT"); len(orders) != 2 {
+		t.Fatalf("esperadas 2 ordens abertas, obtidas %d", len(orders))
+	}
+
+	e.Tick("BTCUSDT", 107.5, t0.Add(4*time.Minute))
+	if positions, _ := e.GetPositions("BTCUSDT"); len(positions) != 0 {
+		t.Fatalf("trailing deveria ter fechado a posição: %+v", positions)
+	}
+	orders, _ := e.GetOpenOrders("BTCUSDT")
+	if len(orders) != 1 || orders[0].OrderID != stop.OrderID {
+		t.Fatalf("só o stop deveria continuar aberto: %+v", orders)
+	}
+
+	// O stop sem posição expira ao disparar
+	e.Tick("BTCUSDT", 94, t0.Add(5*time.Minute))
+	if orders, _ := e.GetOpenOrders(""); len(orders) != 0 {
+		t.Errorf("stop deveria ter expirado: %+v", orders)
+	}
+	if _, err := e.CancelOrder("BTCUSDT", trail.OrderID); !binance.IsUnknownOrder(err) {
+		t.Errorf("trailing executado não deveria ser cancelável: %v", err)
+	}
+}
+
+func TestFundingAndLiquidation(t *testing.T) {
+	e := New(Config{InitialBalance: 1000, Leverage: 10, MaintenanceMarginPct: 0.5, FundingRatePct: 0.01, FundingInterval: 8 * time.Hour}, nil)
+	e.Tick("ETHUSDT", 100, t0)
+	e.PlaceMarketOrder("ETHUSDT", "SELL", 50, false)
+
+	// Dois períodos de funding (08:00 e 16:00); vendido recebe
+	e.Tick("ETHUSDT", 100, t0.Add(16*time.Hour))
+	if eq, _ := e.GetAccountEquity(); !approx(eq, 1001) {
+		t.Errorf("equity após funding %v, esperado 1001", eq)
+	}
+
+	// Liquidação em 100 * (1 + 0.1 - 0.005) = 109.5
+	e.Tick("ETHUSDT", 112, t0.Add(17*time.Hour))
+	if positions, _ := e.GetPositions(""); len(positions) != 0 {
+		t.Fatalf("posição deveria ter sido liquidada: %+v", positions)
+	}
+	if eq, _ := e.GetAccountEquity(); !approx(eq, 1001-475) {
+		t.Errorf("equity após liquidação %v, esperado %v", eq, 1001-475.0)
+	}
+}
+
+func TestOpenRestoresAccount(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "paper.json")
+	cfg := Config{InitialBalance: 1000, Leverage: 10}
+	e, err := Open(path, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Tick("BTCUSDT", 100, t0)
+	e.PlaceMarketOrder("BTCUSDT", "BUY", 10, false)
+	stop, _ := e.PlaceStopMarketOrder("BTCUSDT", "SELL", 0, 90)
+	trail, _ := e.PlaceTrailingStopMarketOrder("BTCUSDT", "SELL", 10, 0, 1)
+	e.Tick("BTCUSDT", 105, t0.Add(time.Minute))
+
+	// Reabre como após um restart
+	e, err = Open(path, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	positions, _ := e.GetPositions("BTCUSDT")
+	if len(positions) != 1 || positions[0].PositionAmt != 10 || positions[0].EntryPrice != 100 {
+		t.Fatalf("posição não restaurada: %+v", positions)
+	}
+	orders, _ := e.GetOpenOrders("")
+	if len(orders) != 2 || orders[0].OrderID != stop.OrderID || orders[1].OrderID != trail.OrderID {
+		t.Fatalf("ordens não restauradas: %+v", orders)
+	}
+	// O trailing continua do extremo 105: 1% de recuo dispara em 103.95
+	e.Tick("BTCUSDT", 104, t0.Add(2*time.Minute))
+	e.Tick("BTCUSDT", 103.9, t0.Add(3*time.Minute))
+	if positions, _ := e.GetPositions(""); len(positions) != 0 {
+		t.Errorf("trailing restaurado deveria ter fechado a posição: %+v", positions)
+	}
+	if next, _ := e.PlaceLimitOrder("BTCUSDT", "BUY", 1, 50, ""); next.OrderID <= trail.OrderID {
+		t.Errorf("ids de ordem reaproveitados: %d", next.OrderID)
+	}
+}
@@ -0,0 +1,27 @@
+package sim
+
+// Modelo de execução compartilhado pela conta simulada e pelo backtest, para
+// que as duas simulações cobrem os mesmos preços e taxas
+
+// MarketPrice piora price pela slippage (em %) de uma execução a mercado do
+// lado side
+func MarketPrice(price float64, side string, slippagePct float64) float64 {
+	if side == "SELL" {
+		return price * (1 - slippagePct/100)
+	}
+	return price * (1 + slippagePct/100)
+}
+
+// Fee é a taxa de uma execução de qty a price, em % do notional
+func Fee(price, qty, feePct float64) float64 {
+	return price * qty * feePct / 100
+}
+
+// PnL é o resultado bruto de qty de uma posição do lado side aberta em entry
+// e avaliada (ou fechada) a price
+func PnL(side string, entry, price, qty float64) float64 {
+	if side == "SELL" {
+		return (entry - price) * qty
+	}
+	return (price - entry) * qty
+}
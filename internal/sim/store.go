@@ -0,0 +1,162 @@
+package sim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"binance-bot/internal/binance"
+)
+
+// snapshot é o estado da conta simulada gravado em disco, para que o paper
+// trading continue de onde parou entre execuções do bot
+type snapshot struct {
+	Wallet      float64                  `json:"wallet"`
+	Positions   map[string]savedPosition `json:"positions"`
+	Orders      []savedOrder             `json:"orders"`
+	NextID      int64                    `json:"next_id"`
+	LastFunding time.Time                `json:"last_funding"`
+	UpdatedAt   time.Time                `json:"updated_at"`
+}
+
+type savedPosition struct {
+	Amt        float64 `json:"amt"`
+	EntryPrice float64 `json:"entry_price"`
+}
+
+type savedOrder struct {
+	Symbol        string  `json:"symbol"`
+	OrderID       int64   `json:"order_id"`
+	Side          string  `json:"side"`
+	Type          string  `json:"type"`
+	TimeInForce   string  `json:"time_in_force,omitempty"`
+	Quantity      float64 `json:"quantity"`
+	Price         float64 `json:"price,omitempty"`
+	StopPrice     float64 `json:"stop_price,omitempty"`
+	ReduceOnly    bool    `json:"reduce_only,omitempty"`
+	ClosePosition bool    `json:"close_position,omitempty"`
+	Activation    float64 `json:"activation,omitempty"`
+	Callback      float64 `json:"callback,omitempty"`
+	Active        bool    `json:"active,omitempty"`
+	Extreme       float64 `json:"extreme,omitempty"`
+	UpdateTime    int64   `json:"update_time"`
+}
+
+// Open cria a conta simulada persistida em path. Se o arquivo existir, saldo,
+// posições e ordens abertas são restaurados; senão a conta começa com
+// cfg.InitialBalance.
+func Open(path string, cfg Config, market MarketData) (*Exchange, error) {
+	e := New(cfg, market)
+	e.path = path
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return e, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler conta simulada: %w", err)
+	}
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("conta simulada inválida em %s: %w", path, err)
+	}
+	e.wallet = snap.Wallet
+	e.nextID = snap.NextID
+	e.lastFunding = snap.LastFunding
+	for symbol, p := range snap.Positions {
+		e.positions[symbol] = &position{amt: p.Amt, entryPrice: p.EntryPrice}
+	}
+	for _, o := range snap.Orders {
+		e.orders[o.OrderID] = &order{
+			Order: binance.Order{
+				Symbol:        o.Symbol,
+				OrderID:       o.OrderID,
+				Side:          o.Side,
+				Type:          o.Type,
+				Status:        binance.OrderStatusNew,
+				TimeInForce:   o.TimeInForce,
+				OrigQty:       o.Quantity,
+				Price:         o.Price,
+				StopPrice:     o.StopPrice,
+				ReduceOnly:    o.ReduceOnly,
+				ClosePosition: o.ClosePosition,
+				UpdateTime:    o.UpdateTime,
+			},
+			activation: o.Activation,
+			callback:   o.Callback,
+			active:     o.Active,
+			extreme:    o.Extreme,
+		}
+	}
+	return e, nil
+}
+
+// flush grava a conta se algo mudou desde a última gravação. Falhas são
+// registradas e a simulação continua em memória.
+func (e *Exchange) flush() {
+	if !e.dirty || e.path == "" {
+		return
+	}
+	e.dirty = false
+	if err := e.save(); err != nil {
+		log.Println("⚠️", err)
+	}
+}
+
+// save grava a conta de forma atômica (arquivo temporário + rename)
+func (e *Exchange) save() error {
+	snap := snapshot{
+		Wallet:      e.wallet,
+		Positions:   make(map[string]savedPosition, len(e.positions)),
+		NextID:      e.nextID,
+		LastFunding: e.lastFunding,
+		UpdatedAt:   time.Now(),
+	}
+	for symbol, p := range e.positions {
+		snap.Positions[symbol] = savedPosition{Amt: p.amt, EntryPrice: p.entryPrice}
+	}
+	for _, o := range e.sortedOrders("") {
+		snap.Orders = append(snap.Orders, savedOrder{
+			Symbol:        o.Symbol,
+			OrderID:       o.OrderID,
+			Side:          o.Side,
+			Type:          o.Type,
+			TimeInForce:   o.TimeInForce,
+			Quantity:      o.OrigQty,
+			Price:         o.Price,
+			StopPrice:     o.StopPrice,
+			ReduceOnly:    o.ReduceOnly,
+			ClosePosition: o.ClosePosition,
+			Activation:    o.activation,
+			Callback:      o.callback,
+			Active:        o.active,
+			Extreme:       o.extreme,
+			UpdateTime:    o.UpdateTime,
+		})
+	}
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(e.path), ".paper-*")
+	if err != nil {
+		return fmt.Errorf("erro ao salvar conta simulada: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("erro ao salvar conta simulada: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("erro ao salvar conta simulada: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), e.path)
+}
@@ -0,0 +1,190 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"binance-bot/internal/protection"
+)
+
+// Quantidade máxima de PnLs realizados mantidos no histórico
+const maxTrades = 500
+
+// Position é o que o bot sabe de uma posição aberta além do que a corretora
+// informa: metadados da entrada, o pico de PnL do trailing, as ordens de
+// proteção abertas e o stop/take profit sugeridos pelo sinal de entrada (em
+// PnL%, zero usa a configuração)
+type Position struct {
+	Side          string            `json:"side"`
+	EntryPrice    float64           `json:"entry_price"`
+	Quantity      float64           `json:"quantity"`
+	Notional      float64           `json:"notional"`
+	Interval      string            `json:"interval,omitempty"`
+	Strategy      string            `json:"strategy,omitempty"`
+	OpenedAt      time.Time         `json:"opened_at"`
+	MaxPnL        float64           `json:"max_pnl"`
+	StopLossPnL   float64           `json:"stop_loss_pnl,omitempty"`
+	TakeProfitPnL float64           `json:"take_profit_pnl,omitempty"`
+	Protection    protection.Orders `json:"protection"`
+}
+
+// State é o conteúdo persistido do arquivo de estado
+type State struct {
+	Positions map[string]Position `json:"positions"`
+	Trades    []float64           `json:"trades"` // PnL realizado dos últimos trades, para os sizers
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// Store guarda o estado do bot em um arquivo JSON reescrito de forma atômica
+// a cada alteração, para que um restart retome trailing e proteções
+type Store struct {
+	mu    sync.Mutex
+	path  string
+	state State
+}
+
+// Open carrega o estado salvo em path, se existir. Com path vazio o estado
+// fica só em memória.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, state: State{Positions: make(map[string]Position)}}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler estado do bot: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		return nil, fmt.Errorf("estado do bot inválido: %w", err)
+	}
+	if s.state.Positions == nil {
+		s.state.Positions = make(map[string]Position)
+	}
+	return s, nil
+}
+
+// Position retorna o estado salvo de uma posição
+func (s *Store) Position(symbol string) (Position, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.state.Positions[symbol]
+	return p, ok
+}
+
+// Positions retorna uma cópia de todas as posições salvas
+func (s *Store) Positions() map[string]Position {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]Position, len(s.state.Positions))
+	for k, v := range s.state.Positions {
+		out[k] = v
+	}
+	return out
+}
+
+// SetPosition grava o estado de uma posição
+func (s *Store) SetPosition(symbol string, p Position) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Positions[symbol] = p
+	s.persist()
+}
+
+// UpdateMaxPnL grava o novo pico de PnL do trailing, se mudou
+func (s *Store) UpdateMaxPnL(symbol string, maxPnL float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.state.Positions[symbol]
+	if !ok || p.MaxPnL == maxPnL {
+		return
+	}
+	p.MaxPnL = maxPnL
+	s.state.Positions[symbol] = p
+	s.persist()
+}
+
+// SetProtection grava as ordens de proteção abertas de uma posição
+func (s *Store) SetProtection(symbol string, orders protection.Orders) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.state.Positions[symbol]
+	if !ok || p.Protection == orders {
+		return
+	}
+	p.Protection = orders
+	s.state.Positions[symbol] = p
+	s.persist()
+}
+
+// RemovePosition esquece uma posição encerrada
+func (s *Store) RemovePosition(symbol string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.state.Positions[symbol]; !ok {
+		return
+	}
+	delete(s.state.Positions, symbol)
+	s.persist()
+}
+
+// RecordTrade adiciona o PnL realizado de um trade ao histórico
+func (s *Store) RecordTrade(pnl float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Trades = append(s.state.Trades, pnl)
+	if len(s.state.Trades) > maxTrades {
+		s.state.Trades = s.state.Trades[len(s.state.Trades)-maxTrades:]
+	}
+	s.persist()
+}
+
+// Trades retorna uma cópia do histórico de PnL realizado
+func (s *Store) Trades() []float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]float64(nil), s.state.Trades...)
+}
+
+func (s *Store) persist() {
+	s.state.UpdatedAt = time.Now()
+	if err := s.save(); err != nil {
+		log.Println("⚠️", err)
+	}
+}
+
+// save grava o estado de forma atômica (arquivo temporário + rename)
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".state-*")
+	if err != nil {
+		return fmt.Errorf("erro ao salvar estado do bot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("erro ao salvar estado do bot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("erro ao salvar estado do bot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,57 @@
+// internal/state/state_test.go
+package state
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"binance-bot/internal/protection"
+)
+
+func TestStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opened := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.SetPosition("BTCUSDT", Position{Side: "BUY", EntryPrice: 50000, Quantity: 0.01, Notional: 500, Interval: "5m", OpenedAt: opened})
+	s.UpdateMaxPnL("BTCUSDT", 7.5)
+	s.SetProtection("BTCUSDT", protection.Orders{Side: "BUY", StopOrderID: 11, StopPrice: 49000})
+	s.SetPosition("ETHUSDT", Position{Side: "SELL"})
+	s.RemovePosition("ETHUSDT")
+	s.RecordTrade(-2)
+	s.RecordTrade(3)
+
+	// Reabre como após um restart
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, ok := s.Position("BTCUSDT")
+	if !ok {
+		t.Fatal("posição de BTCUSDT perdida")
+	}
+	if p.MaxPnL != 7.5 || p.Side != "BUY" || p.Interval != "5m" || !p.OpenedAt.Equal(opened) ||
+		p.Protection.StopOrderID != 11 || p.Protection.StopPrice != 49000 {
+		t.Errorf("Position = %+v", p)
+	}
+	if _, ok := s.Position("ETHUSDT"); ok {
+		t.Error("posição removida voltou após reabrir")
+	}
+	if trades := s.Trades(); len(trades) != 2 || trades[0] != -2 || trades[1] != 3 {
+		t.Errorf("Trades = %v", trades)
+	}
+}
+
+func TestStoreTradesCapped(t *testing.T) {
+	s, _ := Open("")
+	for i := 0; i < maxTrades+10; i++ {
+		s.RecordTrade(float64(i))
+	}
+	trades := s.Trades()
+	if len(trades) != maxTrades || trades[0] != 10 {
+		t.Errorf("len = %d, primeiro = %v; want %d, 10", len(trades), trades[0], maxTrades)
+	}
+}
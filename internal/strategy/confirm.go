@@ -0,0 +1,81 @@
+package strategy
+
+import (
+	"fmt"
+
+	"binance-bot/internal/types"
+)
+
+// Confirmed filtra os sinais de outra estratégia pela tendência de intervalos
+// maiores: um BUY só passa se, em todos os intervalos de confirmação, o último
+// fechamento estiver acima da média móvel de MAPeriod candles e a média
+// estiver subindo (o inverso para SELL). Sem klines suficientes em algum
+// intervalo o sinal é descartado.
+type Confirmed struct {
+	Strategy
+	Intervals []string
+	MAPeriod  int
+}
+
+// Confirm envolve s com a confirmação nos intervalos informados
+func Confirm(s Strategy, intervals []string, maPeriod int) (*Confirmed, error) {
+	if len(intervals) == 0 {
+		return nil, fmt.Errorf("confirmação de %s sem intervalos", s.Name())
+	}
+	if maPeriod < 1 {
+		return nil, fmt.Errorf("período da média de confirmação inválido: %d", maPeriod)
+	}
+	return &Confirmed{Strategy: s, Intervals: intervals, MAPeriod: maPeriod}, nil
+}
+
+func (c *Confirmed) Timeframes() []string { return c.Intervals }
+
+// TimeframeLookback cobre a média atual e a do candle anterior
+func (c *Confirmed) TimeframeLookback() int { return c.MAPeriod + 1 }
+
+func (c *Confirmed) Evaluate(ctx Context) Signal {
+	sig := c.Strategy.Evaluate(ctx)
+	if sig.None() {
+		return sig
+	}
+	for _, interval := range c.Intervals {
+		side, ma, ok := c.trend(ctx.Higher[interval])
+		if !ok || side != sig.Side {
+			return Signal{}
+		}
+		dir := "alta"
+		if side == "SELL" {
+			dir = "baixa"
+		}
+		sig.Reasons = append(sig.Reasons, fmt.Sprintf("Tendência de %s no %s (média %d)", dir, interval, c.MAPeriod))
+		sig.Indicators = append(sig.Indicators, Indicator{Name: fmt.Sprintf("MA%d %s", c.MAPeriod, interval), Value: ma})
+	}
+	return sig
+}
+
+// trend retorna o lado a favor da tendência dos klines ("BUY", "SELL" ou
+// vazio se indefinida) e a média atual. ok é false sem klines suficientes.
+func (c *Confirmed) trend(klines []types.Kline) (side string, ma float64, ok bool) {
+	if len(klines) < c.TimeframeLookback() {
+		return "", 0, false
+	}
+	n := len(klines)
+	ma, prev := sma(klines[n-c.MAPeriod:]), sma(klines[n-c.MAPeriod-1:n-1])
+	last := klines[n-1].Close
+	switch {
+	case last > ma && ma > prev:
+		return "BUY", ma, true
+	case last < ma && ma < prev:
+		return "SELL", ma, true
+	}
+	return "", ma, true
+}
+
+// sma é a média simples dos fechamentos
+func sma(klines []types.Kline) float64 {
+	var sum float64
+	for _, k := range klines {
+		sum += k.Close
+	}
+	return sum / float64(len(klines))
+}
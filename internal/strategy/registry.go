@@ -0,0 +1,190 @@
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+
+	"binance-bot/config"
+)
+
+// Params são os parâmetros de uma estratégia lidos da configuração
+type Params map[string]string
+
+// Int lê um parâmetro inteiro; ausente ou inválido usa def
+func (p Params) Int(key string, def int) int {
+	v, ok := p[key]
+	if !ok {
+		return def
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("⚠️ Parâmetro inválido %s=%q, usando %v", key, v, def)
+		return def
+	}
+	return i
+}
+
+// Float lê um parâmetro decimal; ausente ou inválido usa def
+func (p Params) Float(key string, def float64) float64 {
+	v, ok := p[key]
+	if !ok {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Printf("⚠️ Parâmetro inválido %s=%q, usando %v", key, v, def)
+		return def
+	}
+	return f
+}
+
+// Factory cria uma estratégia a partir dos seus parâmetros
+type Factory func(p Params) (Strategy, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register disponibiliza uma estratégia pelo nome. Registrar o mesmo nome
+// duas vezes é um erro de programação.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[name]; ok {
+		panic("estratégia registrada duas vezes: " + name)
+	}
+	registry[name] = factory
+}
+
+// New instancia a estratégia registrada com o nome informado
+func New(name string, p Params) (Strategy, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("estratégia desconhecida: %q (disponíveis: %v)", name, Names())
+	}
+	s, err := factory(p)
+	if err != nil {
+		return nil, fmt.Errorf("estratégia %s: %w", name, err)
+	}
+	return s, nil
+}
+
+// Names lista as estratégias registradas
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Set guarda a estratégia de cada símbolo
+type Set struct {
+	def      Strategy
+	bySymbol map[string]Strategy
+}
+
+// NewSet instancia as estratégias configuradas, envolvidas em Confirmed quando
+// o símbolo tem intervalos de confirmação. Símbolos com a mesma estratégia
+// compartilham a mesma instância.
+func NewSet(cfg config.StrategyConfig) (*Set, error) {
+	all := make(map[string]Strategy)
+	get := func(name string) (Strategy, error) {
+		if s, ok := all[name]; ok {
+			return s, nil
+		}
+		s, err := New(name, cfg.Params[name])
+		if err != nil {
+			return nil, err
+		}
+		all[name] = s
+		return s, nil
+	}
+
+	confirm := func(s Strategy, intervals []string) (Strategy, error) {
+		if len(intervals) == 0 {
+			return s, nil
+		}
+		return Confirm(s, intervals, cfg.ConfirmMA)
+	}
+
+	base, err := get(cfg.Default)
+	if err != nil {
+		return nil, err
+	}
+	def, err := confirm(base, cfg.Confirm)
+	if err != nil {
+		return nil, err
+	}
+	set := &Set{def: def, bySymbol: make(map[string]Strategy)}
+	symbols := make(map[string]bool)
+	for symbol := range cfg.PerSymbol {
+		symbols[symbol] = true
+	}
+	for symbol := range cfg.ConfirmPerSymbol {
+		symbols[symbol] = true
+	}
+	for symbol := range symbols {
+		name := cfg.Default
+		if n, ok := cfg.PerSymbol[symbol]; ok {
+			name = n
+		}
+		s, err := get(name)
+		if err == nil {
+			s, err = confirm(s, cfg.ConfirmFor(symbol))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", symbol, err)
+		}
+		set.bySymbol[symbol] = s
+	}
+	return set, nil
+}
+
+// For retorna a estratégia do símbolo ou a padrão
+func (s *Set) For(symbol string) Strategy {
+	if st, ok := s.bySymbol[symbol]; ok {
+		return st
+	}
+	return s.def
+}
+
+// Timeframes retorna os intervalos extras pedidos pela estratégia do símbolo
+func (s *Set) Timeframes(symbol string) []string {
+	return Timeframes(s.For(symbol))
+}
+
+// TimeframeLookback retorna o número de klines que a estratégia do símbolo
+// exige em cada intervalo extra, ou zero se ela não usar outros intervalos
+func (s *Set) TimeframeLookback(symbol string) int {
+	if m, ok := s.For(symbol).(MultiTimeframe); ok {
+		return m.TimeframeLookback()
+	}
+	return 0
+}
+
+// MaxLookback é o maior número de klines exigido entre as estratégias, em
+// qualquer intervalo
+func (s *Set) MaxLookback() int {
+	lookback := 0
+	add := func(st Strategy) {
+		lookback = max(lookback, st.Lookback())
+		if m, ok := st.(MultiTimeframe); ok {
+			lookback = max(lookback, m.TimeframeLookback())
+		}
+	}
+	add(s.def)
+	for _, st := range s.bySymbol {
+		add(st)
+	}
+	return lookback
+}
@@ -0,0 +1,111 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+
+	"binance-bot/internal/indicators"
+)
+
+func init() {
+	Register("rsi_macd", func(p Params) (Strategy, error) {
+		s := &RSIMACD{
+			RSIPeriod:    p.Int("rsi_period", 14),
+			RSILevel:     p.Float("rsi_level", 50),
+			MACDFast:     p.Int("macd_fast", 12),
+			MACDSlow:     p.Int("macd_slow", 26),
+			MACDSignal:   p.Int("macd_signal", 9),
+			VolumePeriod: p.Int("volume_period", 10),
+			ATRPeriod:    p.Int("atr_period", 14),
+			ATRStop:      p.Float("atr_stop", 0),
+			RewardRisk:   p.Float("reward_risk", 0),
+		}
+		if s.RSIPeriod < 1 || s.MACDFast < 1 || s.MACDSignal < 1 || s.VolumePeriod < 1 || s.MACDFast >= s.MACDSlow || s.ATRPeriod < 1 || s.ATRStop < 0 || s.RewardRisk < 0 {
+			return nil, fmt.Errorf("parâmetros inválidos: %+v", *s)
+		}
+		return s, nil
+	})
+}
+
+// RSIMACD entra quando o RSI cruza RSILevel com o histograma do MACD
+// acelerando na mesma direção por três candles e volume acima da média.
+// Com ATRStop positivo sugere stop a ATRStop ATRs do fechamento e, com
+// RewardRisk, alvo a RewardRisk vezes essa distância.
+type RSIMACD struct {
+	RSIPeriod    int
+	RSILevel     float64
+	MACDFast     int
+	MACDSlow     int
+	MACDSignal   int
+	VolumePeriod int
+	ATRPeriod    int
+	ATRStop      float64
+	RewardRisk   float64
+}
+
+func (s *RSIMACD) Name() string { return "rsi_macd" }
+
+// Lookback cobre a EMA lenta, a linha de sinal e os três histogramas comparados
+func (s *RSIMACD) Lookback() int {
+	n := max(s.MACDSlow+s.MACDSignal+3, s.RSIPeriod+2, s.VolumePeriod)
+	if s.ATRStop > 0 {
+		n = max(n, s.ATRPeriod+1)
+	}
+	return n
+}
+
+func (s *RSIMACD) Evaluate(ctx Context) Signal {
+	klines := ctx.Klines
+	if len(klines) < s.Lookback() {
+		return Signal{}
+	}
+
+	closes := indicators.ExtractClosePrices(klines)
+	volumes := indicators.ExtractVolumes(klines)
+
+	rsi := indicators.ComputeRSI(closes, s.RSIPeriod)
+	macd, signal, hist := indicators.ComputeMACD(closes, s.MACDFast, s.MACDSlow, s.MACDSignal)
+	volMA := indicators.ComputeVolumeMA(volumes, s.VolumePeriod)[len(volumes)-1]
+
+	if len(rsi) >= 2 && len(hist) >= 3 && len(macd) >= 2 && len(signal) >= 2 {
+		rsi1 := rsi[len(rsi)-2]
+		rsi2 := rsi[len(rsi)-1]
+		hist1 := hist[len(hist)-3]
+		hist2 := hist[len(hist)-2]
+		hist3 := hist[len(hist)-1]
+		vol := volumes[len(volumes)-1]
+
+		var sig Signal
+		switch {
+		case rsi1 < s.RSILevel && rsi2 > s.RSILevel && hist1 < hist2 && hist2 < hist3 && vol > volMA:
+			sig = Signal{Side: "BUY", Reasons: []string{
+				fmt.Sprintf("RSI cruzou %.0f para cima (%.2f → %.2f)", s.RSILevel, rsi1, rsi2),
+				"Histograma MACD subindo há 3 candles",
+			}}
+		case rsi1 > s.RSILevel && rsi2 < s.RSILevel && hist1 > hist2 && hist2 > hist3 && vol > volMA:
+			sig = Signal{Side: "SELL", Reasons: []string{
+				fmt.Sprintf("RSI cruzou %.0f para baixo (%.2f → %.2f)", s.RSILevel, rsi1, rsi2),
+				"Histograma MACD caindo há 3 candles",
+			}}
+		default:
+			return Signal{}
+		}
+		sig.Reasons = append(sig.Reasons, fmt.Sprintf("Volume %.2f acima da média %.2f", vol, volMA))
+
+		// Confiança: 0.4 por cumprir as condições, até 0.3 pelo afastamento do RSI
+		// do nível (10 pontos) e até 0.3 pelo volume sobre a média (o dobro)
+		sig.Confidence = 0.4 + 0.3*clamp(math.Abs(rsi2-s.RSILevel)/10) + 0.3*clamp(vol/volMA-1)
+		sig.Indicators = []Indicator{
+			{Name: "RSI", Value: rsi2},
+			{Name: "MACD", Value: macd[len(macd)-1]},
+			{Name: "Sinal MACD", Value: signal[len(signal)-1]},
+			{Name: "Histograma", Value: hist3},
+			{Name: "Volume", Value: vol},
+			{Name: "Volume MA", Value: volMA},
+		}
+		atrExits(&sig, klines, s.ATRPeriod, s.ATRStop, s.RewardRisk)
+		return sig
+	}
+
+	return Signal{}
+}
@@ -0,0 +1,74 @@
+package strategy
+
+import (
+	"fmt"
+
+	"binance-bot/internal/indicators"
+)
+
+func init() {
+	Register("rsi_reversion", func(p Params) (Strategy, error) {
+		s := &RSIReversion{
+			Period:     p.Int("period", 14),
+			Oversold:   p.Float("oversold", 30),
+			Overbought: p.Float("overbought", 70),
+			ATRPeriod:  p.Int("atr_period", 14),
+			ATRStop:    p.Float("atr_stop", 0),
+			RewardRisk: p.Float("reward_risk", 0),
+		}
+		if s.Period < 1 || s.Oversold <= 0 || s.Overbought >= 100 || s.Oversold >= s.Overbought || s.ATRPeriod < 1 || s.ATRStop < 0 || s.RewardRisk < 0 {
+			return nil, fmt.Errorf("parâmetros inválidos: %+v", *s)
+		}
+		return s, nil
+	})
+}
+
+// RSIReversion aposta na volta à média: compra quando o RSI sai da zona de
+// sobrevenda e vende quando sai da zona de sobrecompra. ATRStop e RewardRisk
+// sugerem stop e alvo como em RSIMACD.
+type RSIReversion struct {
+	Period     int
+	Oversold   float64
+	Overbought float64
+	ATRPeriod  int
+	ATRStop    float64
+	RewardRisk float64
+}
+
+func (s *RSIReversion) Name() string { return "rsi_reversion" }
+
+func (s *RSIReversion) Lookback() int {
+	if s.ATRStop > 0 {
+		return max(s.Period+2, s.ATRPeriod+1)
+	}
+	return s.Period + 2
+}
+
+func (s *RSIReversion) Evaluate(ctx Context) Signal {
+	if len(ctx.Klines) < s.Lookback() {
+		return Signal{}
+	}
+	rsi := indicators.ComputeRSI(indicators.ExtractClosePrices(ctx.Klines), s.Period)
+	prev, last := rsi[len(rsi)-2], rsi[len(rsi)-1]
+	// Confiança cresce com a profundidade do extremo de onde o RSI saiu
+	var sig Signal
+	switch {
+	case prev < s.Oversold && last >= s.Oversold:
+		sig = Signal{
+			Side:       "BUY",
+			Confidence: 0.5 + 0.5*clamp((s.Oversold-prev)/s.Oversold),
+			Reasons:    []string{fmt.Sprintf("RSI saiu da sobrevenda %.0f (%.2f → %.2f)", s.Oversold, prev, last)},
+		}
+	case prev > s.Overbought && last <= s.Overbought:
+		sig = Signal{
+			Side:       "SELL",
+			Confidence: 0.5 + 0.5*clamp((prev-s.Overbought)/(100-s.Overbought)),
+			Reasons:    []string{fmt.Sprintf("RSI saiu da sobrecompra %.0f (%.2f → %.2f)", s.Overbought, prev, last)},
+		}
+	default:
+		return Signal{}
+	}
+	sig.Indicators = []Indicator{{Name: "RSI anterior", Value: prev}, {Name: "RSI", Value: last}}
+	atrExits(&sig, ctx.Klines, s.ATRPeriod, s.ATRStop, s.RewardRisk)
+	return sig
+}
@@ -1,48 +1,179 @@
 package strategy
 
 import (
+	"fmt"
+	"strings"
+
 	"binance-bot/internal/indicators"
 	"binance-bot/internal/types"
 )
 
-// Sinais possíveis
-const (
-	NoSignal = iota
-	BuySignal
-	SellSignal
-)
+// Context é o que uma estratégia recebe a cada candle fechado. Klines termina
+// no candle que acabou de fechar. Higher traz, por intervalo, os klines já
+// fechados dos intervalos extras pedidos por estratégias MultiTimeframe.
+type Context struct {
+	Symbol   string
+	Interval string
+	Klines   []types.Kline
+	Higher   map[string][]types.Kline
+}
 
-// EvaluateSignal aplica uma estratégia agressiva e altamente assertiva
-func EvaluateSignal(klines []types.Kline, symbol string) int {
-	if len(klines) < 35 {
-		return NoSignal
-	}
+// Indicator é um valor de indicador no candle que gerou o sinal
+type Indicator struct {
+	Name  string
+	Value float64
+}
 
-	closes := indicators.ExtractClosePrices(klines)
-	volumes := indicators.ExtractVolumes(klines)
+// Signal é a decisão de uma estratégia num candle. Além do lado, carrega a
+// confiança (0 a 1), os motivos legíveis, os indicadores que levaram à decisão
+// e, opcionalmente, preços sugeridos de stop loss e take profit. Preço zero
+// deixa a saída com a configuração de trailing.
+type Signal struct {
+	Side       string // "BUY", "SELL" ou vazio sem sinal
+	Confidence float64
+	Reasons    []string
+	Indicators []Indicator
+	StopLoss   float64
+	TakeProfit float64
+}
+
+// None informa que não há sinal
+func (s Signal) None() bool {
+	return s.Side == ""
+}
+
+// StopDistance retorna a distância de price até o stop sugerido, ou zero se
+// não houver stop ou se ele estiver do lado errado do preço
+func (s Signal) StopDistance(price float64) float64 {
+	if s.StopLoss <= 0 {
+		return 0
+	}
+	d := price - s.StopLoss
+	if s.Side == "SELL" {
+		d = -d
+	}
+	return max(d, 0)
+}
 
-	rsi := indicators.ComputeRSI(closes, 14)
-	macd, signal, hist := indicators.ComputeMACD(closes, 12, 26, 9)
-	volMA := indicators.ComputeVolumeMA(volumes, 10)
+// TargetDistance retorna a distância de price até o take profit sugerido, ou
+// zero se não houver alvo ou se ele estiver do lado errado do preço
+func (s Signal) TargetDistance(price float64) float64 {
+	if s.TakeProfit <= 0 {
+		return 0
+	}
+	d := s.TakeProfit - price
+	if s.Side == "SELL" {
+		d = -d
+	}
+	return max(d, 0)
+}
 
-	if len(rsi) >= 2 && len(hist) >= 3 && len(macd) >= 2 && len(signal) >= 2 {
-		rsi1 := rsi[len(rsi)-2]
-		rsi2 := rsi[len(rsi)-1]
-		hist1 := hist[len(hist)-3]
-		hist2 := hist[len(hist)-2]
-		hist3 := hist[len(hist)-1]
-		vol := volumes[len(volumes)-1]
+// ExitPnL converte o stop e o take profit sugeridos em PnL% alavancado para
+// uma entrada em price, no formato de config.TrailingConfig. Níveis ausentes
+// ou inválidos retornam zero.
+func (s Signal) ExitPnL(price, leverage float64) (stopLossPnL, takeProfitPnL float64) {
+	if price <= 0 {
+		return 0, 0
+	}
+	if d := s.StopDistance(price); d > 0 {
+		stopLossPnL = -d / price * leverage * 100
+	}
+	if d := s.TargetDistance(price); d > 0 {
+		takeProfitPnL = d / price * leverage * 100
+	}
+	return stopLossPnL, takeProfitPnL
+}
 
-		// BUY
-		if rsi1 < 50 && rsi2 > 50 && hist1 < hist2 && hist2 < hist3 && vol > volMA {
-			return BuySignal
+// Indicator retorna o valor do indicador name no sinal
+func (s Signal) Indicator(name string) (float64, bool) {
+	for _, ind := range s.Indicators {
+		if ind.Name == name {
+			return ind.Value, true
 		}
+	}
+	return 0, false
+}
 
-		// SELL
-		if rsi1 > 50 && rsi2 < 50 && hist1 > hist2 && hist2 > hist3 && vol > volMA {
-			return SellSignal
+// Summary resume motivos e indicadores em uma linha, para o diário
+func (s Signal) Summary() (reasons, snapshot string) {
+	parts := make([]string, len(s.Indicators))
+	for i, ind := range s.Indicators {
+		parts[i] = fmt.Sprintf("%s=%.4f", ind.Name, ind.Value)
+	}
+	return strings.Join(s.Reasons, "; "), strings.Join(parts, " ")
+}
+
+// Describe formata o sinal para as notificações
+func (s Signal) Describe() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🎯 Confiança: %.0f%%", s.Confidence*100)
+	for _, r := range s.Reasons {
+		fmt.Fprintf(&b, "\n- %s", r)
+	}
+	if len(s.Indicators) > 0 {
+		b.WriteString("\n📊 Indicadores:")
+		for _, ind := range s.Indicators {
+			fmt.Fprintf(&b, "\n- %s: %.4f", ind.Name, ind.Value)
 		}
 	}
+	if s.StopLoss > 0 || s.TakeProfit > 0 {
+		fmt.Fprintf(&b, "\n🛡️ Stop sugerido: %.4f | Alvo: %.4f", s.StopLoss, s.TakeProfit)
+	}
+	return b.String()
+}
+
+// Strategy decide entradas a partir dos klines de um símbolo
+type Strategy interface {
+	// Name é o nome usado na configuração
+	Name() string
+	// Lookback é o número mínimo de klines para a estratégia emitir sinal
+	Lookback() int
+	Evaluate(ctx Context) Signal
+}
+
+// MultiTimeframe é implementada por estratégias que também avaliam klines de
+// outros intervalos. A camada de dados agrega os klines do intervalo base
+// nesses intervalos e os entrega em Context.Higher.
+type MultiTimeframe interface {
+	Strategy
+	// Timeframes lista os intervalos extras usados pela estratégia
+	Timeframes() []string
+	// TimeframeLookback é o número mínimo de klines em cada intervalo extra
+	TimeframeLookback() int
+}
+
+// Timeframes retorna os intervalos extras de s, se houver
+func Timeframes(s Strategy) []string {
+	if m, ok := s.(MultiTimeframe); ok {
+		return m.Timeframes()
+	}
+	return nil
+}
+
+// atrExits sugere stop a atrMult ATRs do último fechamento e alvo a
+// rewardRisk vezes a distância do stop. Multiplicador zero não sugere nada.
+func atrExits(sig *Signal, klines []types.Kline, period int, atrMult, rewardRisk float64) {
+	if atrMult <= 0 {
+		return
+	}
+	atr := indicators.ComputeATR(klines, period)
+	if atr <= 0 {
+		return
+	}
+	price := klines[len(klines)-1].Close
+	dist := atr * atrMult
+	dir := 1.0
+	if sig.Side == "SELL" {
+		dir = -1
+	}
+	sig.Indicators = append(sig.Indicators, Indicator{Name: "ATR", Value: atr})
+	sig.StopLoss = price - dir*dist
+	if rewardRisk > 0 {
+		sig.TakeProfit = price + dir*dist*rewardRisk
+	}
+}
 
-	return NoSignal
+// clamp limita v ao intervalo [0, 1]
+func clamp(v float64) float64 {
+	return min(max(v, 0), 1)
 }
@@ -2,50 +2,318 @@
 package strategy
 
 import (
-	"fmt"
+	"math"
+	"math/rand"
 	"testing"
+
+	"binance-bot/config"
+	"binance-bot/internal/indicators"
+	"binance-bot/internal/types"
 )
 
 // montarKlines constrói klines sintéticos dados slices de close e volume
-func montarKlines(closes, vols []float64) [][]interface{} {
-	res := make([][]interface{}, len(closes))
+func montarKlines(closes, vols []float64) []types.Kline {
+	res := make([]types.Kline, len(closes))
 	for i := range closes {
-		// [openTime, open, high, low, close, volume]
-		res[i] = []interface{}{0, "0", 0, 0, fmt.Sprintf("%f", closes[i]), fmt.Sprintf("%f", vols[i])}
+		res[i] = types.Kline{OpenTime: int64(i) * 60000, Open: closes[i], High: closes[i], Low: closes[i], Close: closes[i], Volume: vols[i]}
 	}
 	return res
 }
 
-func TestEvaluateSignal_Buy(t *testing.T) {
-	// Prepara dados: macd > signal, rsi ~50, volume > MA
-	closes := []float64{1, 1.1, 1.2, 1.3, 1.4}
-	vols := []float64{10, 12, 14, 16, 18}
-	klines := montarKlines(closes, vols)
+// passeio gera n candles de um passeio aleatório determinístico
+func passeio(n int) ([]float64, []float64) {
+	r := rand.New(rand.NewSource(1))
+	var closes, vols []float64
+	price := 100.0
+	for i := 0; i < n; i++ {
+		price += r.NormFloat64()
+		closes = append(closes, price)
+		vols = append(vols, 10+r.Float64()*10)
+	}
+	return closes, vols
+}
 
-	signal := EvaluateSignal(klines)
-	if signal != BuySignal {
-		t.Errorf("EvaluateSignal = %v; want BuySignal", signal)
+// sinais avalia candle a candle com a janela de 100 klines usada ao vivo e
+// retorna o índice (exclusivo) e o sinal de cada candle que gerou sinal
+func sinais(s Strategy, klines []types.Kline) map[int]Signal {
+	out := make(map[int]Signal)
+	for i := 1; i <= len(klines); i++ {
+		if sig := s.Evaluate(Context{Symbol: "BTCUSDT", Klines: klines[max(0, i-100):i]}); !sig.None() {
+			out[i] = sig
+		}
 	}
+	return out
 }
 
-func TestEvaluateSignal_Sell(t *testing.T) {
-	closes := []float64{1, 0.9, 0.8, 0.7, 0.6}
-	vols := []float64{18, 16, 14, 12, 10}
-	klines := montarKlines(closes, vols)
+func novaEstrategia(t *testing.T, name string) Strategy {
+	t.Helper()
+	s, err := New(name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
 
-	signal := EvaluateSignal(klines)
-	if signal != SellSignal {
-		t.Errorf("EvaluateSignal = %v; want SellSignal", signal)
+// conferirCruzamento verifica que o RSI cruzou 50 na direção do sinal
+func conferirCruzamento(t *testing.T, closes []float64, end int, side string) {
+	t.Helper()
+	rsi := indicators.ComputeRSI(closes[max(0, end-100):end], 14)
+	prev, last := rsi[len(rsi)-2], rsi[len(rsi)-1]
+	if (side == "BUY" && !(prev < 50 && last > 50)) || (side == "SELL" && !(prev > 50 && last < 50)) {
+		t.Errorf("%s no candle %d sem cruzamento do RSI: %.2f → %.2f", side, end, prev, last)
+	}
+}
+
+func TestEvaluateSignal_Buy(t *testing.T) {
+	closes, vols := passeio(3000)
+	buys := 0
+	for end, sig := range sinais(novaEstrategia(t, "rsi_macd"), montarKlines(closes, vols)) {
+		if sig.Side == "BUY" {
+			buys++
+			conferirCruzamento(t, closes, end, "BUY")
+		}
+	}
+	if buys == 0 {
+		t.Error("nenhum BUY em 3000 candles")
+	}
+}
+
+func TestEvaluateSignal_Sell(t *testing.T) {
+	closes, vols := passeio(3000)
+	sells := 0
+	for end, sig := range sinais(novaEstrategia(t, "rsi_macd"), montarKlines(closes, vols)) {
+		if sig.Side == "SELL" {
+			sells++
+			conferirCruzamento(t, closes, end, "SELL")
+		}
+	}
+	if sells == 0 {
+		t.Error("nenhum SELL em 3000 candles")
 	}
 }
 
 func TestEvaluateSignal_NoSignal(t *testing.T) {
-	closes := []float64{1, 1, 1, 1, 1}
-	vols := []float64{10, 10, 10, 10, 10}
+	// Mesmo passeio, mas o volume nunca fica acima da média
+	closes, _ := passeio(3000)
+	vols := make([]float64, len(closes))
+	for i := range vols {
+		vols[i] = 10
+	}
+	if got := sinais(novaEstrategia(t, "rsi_macd"), montarKlines(closes, vols)); len(got) != 0 {
+		t.Errorf("%d sinais sem volume; want nenhum", len(got))
+	}
+
+	// Poucos klines
+	s := novaEstrategia(t, "rsi_macd")
+	klines := montarKlines(closes[:s.Lookback()-1], vols[:s.Lookback()-1])
+	if sig := s.Evaluate(Context{Klines: klines}); !sig.None() {
+		t.Errorf("sinal com %d klines = %+v; want nenhum", len(klines), sig)
+	}
+}
+
+// macdRef calcula MACD, sinal e histograma de referência no último candle e
+// nos dois anteriores, com as EMAs alinhadas pelo mesmo candle
+func macdRef(closes []float64, fast, slow, signal int) (macd, sig float64, hist [3]float64) {
+	ema := func(data []float64, period int) []float64 {
+		out := make([]float64, len(data))
+		var sum float64
+		for i, v := range data {
+			switch {
+			case i < period-1:
+				sum += v
+			case i == period-1:
+				out[i] = (sum + v) / float64(period)
+			default:
+				out[i] = out[i-1] + (v-out[i-1])*2/float64(period+1)
+			}
+		}
+		return out
+	}
+	f, sl := ema(closes, fast), ema(closes, slow)
+	line := make([]float64, 0, len(closes))
+	for i := slow - 1; i < len(closes); i++ {
+		line = append(line, f[i]-sl[i])
+	}
+	sigLine := ema(line, signal)
+	n := len(line)
+	for j := 0; j < 3; j++ {
+		hist[j] = line[n-3+j] - sigLine[n-3+j]
+	}
+	return line[n-1], sigLine[n-1], hist
+}
+
+func TestRSIMACDSignalsUseAlignedMACD(t *testing.T) {
+	closes, vols := passeio(3000)
+	found := sinais(novaEstrategia(t, "rsi_macd"), montarKlines(closes, vols))
+	if len(found) == 0 {
+		t.Fatal("nenhum sinal em 3000 candles")
+	}
+	for end, sig := range found {
+		macd, signal, hist := macdRef(closes[max(0, end-100):end], 12, 26, 9)
+		got, _ := sig.Indicator("MACD")
+		gotSignal, _ := sig.Indicator("Sinal MACD")
+		if math.Abs(got-macd) > 1e-9 || math.Abs(gotSignal-signal) > 1e-9 {
+			t.Errorf("candle %d: MACD %v / %v; want %v / %v", end, got, gotSignal, macd, signal)
+		}
+		rising := hist[0] < hist[1] && hist[1] < hist[2]
+		falling := hist[0] > hist[1] && hist[1] > hist[2]
+		if (sig.Side == "BUY" && !rising) || (sig.Side == "SELL" && !falling) {
+			t.Errorf("candle %d: %s com histograma %v", end, sig.Side, hist)
+		}
+	}
+}
+
+func TestSignalDetails(t *testing.T) {
+	closes, vols := passeio(3000)
 	klines := montarKlines(closes, vols)
+	s, err := New("rsi_macd", Params{"atr_stop": "2", "reward_risk": "1.5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for end, sig := range sinais(s, klines) {
+		price := closes[end-1]
+		if sig.Confidence < 0.4 || sig.Confidence > 1 || len(sig.Reasons) != 3 {
+			t.Errorf("candle %d: confiança %v, motivos %q", end, sig.Confidence, sig.Reasons)
+		}
+		if _, ok := sig.Indicator("RSI"); !ok {
+			t.Errorf("candle %d sem RSI nos indicadores: %+v", end, sig.Indicators)
+		}
+		stop, target := sig.StopDistance(price), sig.TargetDistance(price)
+		if stop <= 0 || math.Abs(target-1.5*stop) > 1e-9 {
+			t.Errorf("candle %d %s: stop %v alvo %v a partir de %v", end, sig.Side, sig.StopLoss, sig.TakeProfit, price)
+		}
+	}
+}
+
+func TestSignalExitPnL(t *testing.T) {
+	sig := Signal{Side: "SELL", StopLoss: 101, TakeProfit: 97}
+	stopPnL, tpPnL := sig.ExitPnL(100, 10)
+	if math.Abs(stopPnL+10) > 1e-9 || math.Abs(tpPnL-30) > 1e-9 {
+		t.Errorf("ExitPnL = %v, %v; want -10, 30", stopPnL, tpPnL)
+	}
+	// Níveis do lado errado do preço são ignorados
+	sig = Signal{Side: "BUY", StopLoss: 101, TakeProfit: 97}
+	if stopPnL, tpPnL := sig.ExitPnL(100, 10); stopPnL != 0 || tpPnL != 0 {
+		t.Errorf("ExitPnL com níveis invertidos = %v, %v; want 0, 0", stopPnL, tpPnL)
+	}
+	if stopPnL, tpPnL := (Signal{Side: "BUY"}).ExitPnL(100, 10); stopPnL != 0 || tpPnL != 0 {
+		t.Errorf("ExitPnL sem sugestão = %v, %v; want 0, 0", stopPnL, tpPnL)
+	}
+}
+
+func TestRSIReversion(t *testing.T) {
+	// Queda contínua leva o RSI a zero; a retomada o tira da sobrevenda
+	closes := []float64{}
+	vols := []float64{}
+	for i := 0; i < 20; i++ {
+		closes = append(closes, 100-float64(i))
+		vols = append(vols, 10)
+	}
+	s := novaEstrategia(t, "rsi_reversion")
+	if sig := s.Evaluate(Context{Klines: montarKlines(closes, vols)}); !sig.None() {
+		t.Errorf("sinal em queda = %+v; want nenhum", sig)
+	}
+	for i := 0; i < 5; i++ {
+		closes = append(closes, closes[len(closes)-1]+3)
+		vols = append(vols, 10)
+	}
+	// Com duas altas de 3 contra doze quedas de 1 o RSI vai a 33
+	found := sinais(s, montarKlines(closes, vols))
+	if len(found) != 1 || found[22].Side != "BUY" {
+		t.Errorf("sinais = %+v; want BUY no candle 22", found)
+	}
+}
+
+// fixa sempre emite o mesmo sinal
+type fixa struct{ side string }
+
+func (f fixa) Name() string                { return "fixa" }
+func (f fixa) Lookback() int               { return 1 }
+func (f fixa) Evaluate(ctx Context) Signal { return Signal{Side: f.side, Reasons: []string{"fixa"}} }
+
+func TestConfirmed(t *testing.T) {
+	subida := make([]float64, 30)
+	for i := range subida {
+		subida[i] = 100 + float64(i)
+	}
+	vols := make([]float64, 30)
+	higher := map[string][]types.Kline{"15m": montarKlines(subida, vols), "1h": montarKlines(subida, vols)}
+	ctx := Context{Symbol: "BTCUSDT", Klines: montarKlines([]float64{1}, []float64{1}), Higher: higher}
+
+	buy, err := Confirm(fixa{"BUY"}, []string{"15m", "1h"}, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := buy.Evaluate(ctx)
+	if sig.Side != "BUY" || len(sig.Reasons) != 3 {
+		t.Errorf("BUY em alta nos dois intervalos = %+v", sig)
+	}
+	if _, ok := sig.Indicator("MA20 1h"); !ok {
+		t.Errorf("média do 1h ausente: %+v", sig.Indicators)
+	}
+
+	sell, _ := Confirm(fixa{"SELL"}, []string{"15m"}, 20)
+	if sig := sell.Evaluate(ctx); !sig.None() {
+		t.Errorf("SELL contra a tendência = %+v; want nenhum", sig)
+	}
+	// Poucos klines no intervalo de confirmação descartam o sinal
+	ctx.Higher = map[string][]types.Kline{"15m": higher["15m"][:20], "1h": higher["1h"]}
+	if sig := buy.Evaluate(ctx); !sig.None() {
+		t.Errorf("BUY sem klines suficientes no 15m = %+v; want nenhum", sig)
+	}
+	if _, err := Confirm(fixa{"BUY"}, nil, 20); err == nil {
+		t.Error("confirmação sem intervalos deveria falhar")
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	if _, err := New("inexistente", nil); err == nil {
+		t.Error("estratégia desconhecida deveria falhar")
+	}
+	if _, err := New("rsi_macd", Params{"macd_fast": "30"}); err == nil {
+		t.Error("macd_fast >= macd_slow deveria falhar")
+	}
+
+	set, err := NewSet(config.StrategyConfig{
+		Default:   "rsi_macd",
+		PerSymbol: map[string]string{"ETHUSDT": "rsi_reversion"},
+		Params:    map[string]map[string]string{"rsi_reversion": {"period": "50"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := set.For("BTCUSDT").Name(); got != "rsi_macd" {
+		t.Errorf("BTCUSDT usa %s; want rsi_macd", got)
+	}
+	eth := set.For("ETHUSDT")
+	if eth.Name() != "rsi_reversion" || eth.Lookback() != 52 {
+		t.Errorf("ETHUSDT usa %s com lookback %d; want rsi_reversion com 52", eth.Name(), eth.Lookback())
+	}
+	if set.MaxLookback() != 52 {
+		t.Errorf("MaxLookback = %d; want 52", set.MaxLookback())
+	}
+
+	// Confirmação global com exceção por símbolo
+	set, err = NewSet(config.StrategyConfig{
+		Default:          "rsi_macd",
+		Confirm:          []string{"15m", "1h"},
+		ConfirmPerSymbol: map[string][]string{"ETHUSDT": nil},
+		ConfirmMA:        60,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := set.Timeframes("BTCUSDT"); len(got) != 2 || got[1] != "1h" {
+		t.Errorf("Timeframes(BTCUSDT) = %v; want [15m 1h]", got)
+	}
+	if got := set.Timeframes("ETHUSDT"); len(got) != 0 {
+		t.Errorf("Timeframes(ETHUSDT) = %v; want nenhum", got)
+	}
+	if set.MaxLookback() != 61 {
+		t.Errorf("MaxLookback = %d; want 61", set.MaxLookback())
+	}
 
-	signal := EvaluateSignal(klines)
-	if signal != NoSignal {
-		t.Errorf("EvaluateSignal = %v; want NoSignal", signal)
+	if _, err := NewSet(config.StrategyConfig{Default: "rsi_macd", PerSymbol: map[string]string{"ETHUSDT": "x"}}); err == nil {
+		t.Error("estratégia desconhecida por símbolo deveria falhar")
 	}
 }
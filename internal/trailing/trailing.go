@@ -0,0 +1,95 @@
+package trailing
+
+import (
+	"log"
+	"math"
+
+	"binance-bot/config"
+)
+
+// Modos de trailing
+const (
+	ModeLocal    = "local"
+	ModeExchange = "exchange"
+)
+
+// Limites de callbackRate aceitos pela Binance para TRAILING_STOP_MARKET
+const (
+	minCallbackRate = 0.1
+	maxCallbackRate = 10
+)
+
+// Status guarda o maior PnL atingido por uma posição aberta
+type Status struct {
+	MaxPnL float64
+	Side   string
+}
+
+// Update registra o PnL atual e informa se a posição deve ser encerrada:
+// trailing ativado e recuo de CallbackPnL desde o pico, ou stop loss fixo.
+// No modo exchange o trailing fica a cargo da corretora e só o stop é checado.
+func (s *Status) Update(pnl float64, cfg config.TrailingConfig) bool {
+	if pnl > s.MaxPnL {
+		s.MaxPnL = pnl
+	}
+	if cfg.Mode != ModeExchange && s.MaxPnL >= cfg.ActivatePnL && pnl <= s.MaxPnL-cfg.CallbackPnL {
+		return true
+	}
+	return pnl <= cfg.StopLossPnL
+}
+
+// Active informa se o trailing já foi ativado
+func (s *Status) Active(cfg config.TrailingConfig) bool {
+	return s.MaxPnL >= cfg.ActivatePnL
+}
+
+// StopPnL retorna o nível de saída atual em PnL%: o stop fixo ou, com o
+// trailing ativo, o pico menos o callback.
+func (s *Status) StopPnL(cfg config.TrailingConfig) float64 {
+	if s.Active(cfg) {
+		return s.MaxPnL - cfg.CallbackPnL
+	}
+	return cfg.StopLossPnL
+}
+
+// WithExits aplica à configuração o stop loss e o take profit próprios de uma
+// posição, em PnL%. Zero mantém o valor configurado.
+func WithExits(cfg config.TrailingConfig, stopLossPnL, takeProfitPnL float64) config.TrailingConfig {
+	if stopLossPnL < 0 {
+		cfg.StopLossPnL = stopLossPnL
+	}
+	if takeProfitPnL > 0 {
+		cfg.TakeProfitPnL = takeProfitPnL
+	}
+	return cfg
+}
+
+// PriceForPnL converte um PnL% alavancado no preço equivalente para o lado da posição
+func PriceForPnL(side string, entry, leverage, pnl float64) float64 {
+	move := pnl / 100 / leverage
+	if side == "SELL" {
+		return entry * (1 - move)
+	}
+	return entry * (1 + move)
+}
+
+// CallbackRate converte o callback em PnL% para o callbackRate (% de preço)
+// do TRAILING_STOP_MARKET, limitado à faixa aceita pela Binance. O ajuste à
+// faixa muda o recuo efetivo e por isso é registrado no log.
+func CallbackRate(cfg config.TrailingConfig, leverage float64) float64 {
+	want := cfg.CallbackPnL / leverage
+	rate := math.Max(minCallbackRate, math.Min(maxCallbackRate, want))
+	if rate != want {
+		log.Printf("⚠️ Callback de %.2f%% de PnL com %.0fx é %.3f%% do preço, fora da faixa da Binance: usando %.1f%% (%.2f%% de PnL)",
+			cfg.CallbackPnL, leverage, want, rate, rate*leverage)
+	}
+	return math.Round(rate*10) / 10
+}
+
+// CloseSide retorna o lado da ordem que encerra uma posição
+func CloseSide(side string) string {
+	if side == "SELL" {
+		return "BUY"
+	}
+	return "SELL"
+}
@@ -0,0 +1,66 @@
+// internal/trailing/trailing_test.go
+package trailing
+
+import (
+	"math"
+	"testing"
+
+	"binance-bot/config"
+)
+
+func TestStatusUpdate(t *testing.T) {
+	cfg := config.TrailingConfig{ActivatePnL: 3, CallbackPnL: 1, StopLossPnL: -5}
+	s := &Status{Side: "BUY"}
+
+	for _, pnl := range []float64{1, 2.5, 3.5, 2.8} {
+		if s.Update(pnl, cfg) {
+			t.Fatalf("Update(%v) saiu cedo demais", pnl)
+		}
+	}
+	if !s.Active(cfg) || s.StopPnL(cfg) != 2.5 {
+		t.Errorf("StopPnL = %v; want 2.5", s.StopPnL(cfg))
+	}
+	if !s.Update(2.4, cfg) {
+		t.Error("Update(2.4) deveria sair após recuo de 1% do pico 3.5")
+	}
+
+	s = &Status{Side: "SELL"}
+	if !s.Update(-5, cfg) {
+		t.Error("Update(-5) deveria acionar o stop loss")
+	}
+}
+
+func TestPriceForPnL(t *testing.T) {
+	if p := PriceForPnL("BUY", 100, 20, -5); math.Abs(p-99.75) > 1e-9 {
+		t.Errorf("PriceForPnL BUY = %v; want 99.75", p)
+	}
+	if p := PriceForPnL("SELL", 100, 20, 10); math.Abs(p-99.5) > 1e-9 {
+		t.Errorf("PriceForPnL SELL = %v; want 99.5", p)
+	}
+}
+
+func TestWithExits(t *testing.T) {
+	cfg := config.TrailingConfig{StopLossPnL: -5, TakeProfitPnL: 20}
+	if got := WithExits(cfg, -8, 0); got.StopLossPnL != -8 || got.TakeProfitPnL != 20 {
+		t.Errorf("WithExits(-8, 0) = %+v", got)
+	}
+	if got := WithExits(cfg, 0, 12); got.StopLossPnL != -5 || got.TakeProfitPnL != 12 {
+		t.Errorf("WithExits(0, 12) = %+v", got)
+	}
+}
+
+func TestExchangeMode(t *testing.T) {
+	cfg := config.TrailingConfig{Mode: ModeExchange, ActivatePnL: 3, CallbackPnL: 1, StopLossPnL: -5}
+	s := &Status{Side: "BUY"}
+	s.Update(6, cfg)
+	if s.Update(4, cfg) {
+		t.Error("modo exchange não deveria sair pelo trailing local")
+	}
+	if r := CallbackRate(cfg, 20); r != 0.1 {
+		t.Errorf("CallbackRate = %v; want 0.1 (mínimo da Binance)", r)
+	}
+	cfg.CallbackPnL = 30
+	if r := CallbackRate(cfg, 10); r != 3 {
+		t.Errorf("CallbackRate = %v; want 3", r)
+	}
+}